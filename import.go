@@ -0,0 +1,74 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/importer"
+)
+
+// runImport converts the configuration of another dashboard into a file for
+// the apps directory, written to stdout. Items that could not be converted
+// are reported on stderr. It returns the exit code.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "homer, homepage, dashy or heimdall, detected when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: homedash import [-format <format>] <file>")
+		fmt.Fprintln(flags.Output(), "")
+		fmt.Fprintln(flags.Output(), "The file is a Homer config.yml, a Homepage services.yaml, a Dashy conf.yml, Heimdall's")
+		fmt.Fprintln(flags.Output(), "app.sqlite or a JSON export of its items table. Changes in a Heimdall app.sqlite-wal")
+		fmt.Fprintln(flags.Output(), "are not read, stop Heimdall before importing its database.")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// The icon index is needed to map the icons
+	c.Setup()
+
+	result, err := importer.Convert(*format, data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	dropIn, err := result.DropIn()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	os.Stdout.Write(dropIn)
+
+	for _, unmapped := range result.Unmapped {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", unmapped.Item, unmapped.Reason)
+	}
+	fmt.Fprintf(os.Stderr, "converted %d applications in %d groups from %s\n", len(result.Apps), len(result.Groups), result.Format)
+
+	return 0
+}
@@ -0,0 +1,45 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// APIKeyIdentity returns the identity of the API key sent in the
+// Authorization (as a bearer token) or X-API-Key header, or nil when no
+// known key was sent.
+func APIKeyIdentity(r *http.Request) *Identity {
+	key := r.Header.Get("X-API-Key")
+	if bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		key = strings.TrimSpace(bearer)
+	}
+
+	if key == "" {
+		return nil
+	}
+
+	for _, apiKey := range c.Config.Auth.APIKeys {
+		if apiKey.Key != "" && subtle.ConstantTimeCompare([]byte(apiKey.Key), []byte(key)) == 1 {
+			return &Identity{
+				User:  apiKey.Name,
+				Roles: []string{apiKey.Role},
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/network"
+)
+
+// ForwardedIdentity reads the identity set by a forward-auth proxy like
+// Authelia or Authentik. The headers are only believed when forward-auth is
+// enabled and the request came from a trusted proxy.
+func ForwardedIdentity(r *http.Request) *Identity {
+	cfg := c.Config.Auth.ForwardAuth
+
+	if !cfg.Enabled || !network.IsTrustedProxy(r) {
+		return nil
+	}
+
+	user := strings.TrimSpace(r.Header.Get(cfg.UserHeader))
+	if user == "" {
+		return nil
+	}
+
+	identity := &Identity{
+		User:   user,
+		Email:  strings.TrimSpace(r.Header.Get(cfg.EmailHeader)),
+		Groups: []string{},
+	}
+
+	for _, header := range r.Header.Values(cfg.GroupsHeader) {
+		for _, group := range strings.Split(header, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				identity.Groups = append(identity.Groups, group)
+			}
+		}
+	}
+
+	AssignRoles(identity)
+
+	return identity
+}
@@ -0,0 +1,50 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Identity describes the authenticated user of a request.
+type Identity struct {
+	User   string   `json:"user"`
+	Email  string   `json:"email,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+// NewContext returns a copy of the context carrying the identity.
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity of the request, or nil for anonymous requests.
+func FromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(contextKey{}).(*Identity)
+	return identity
+}
+
+// InGroup reports whether the identity is a member of the group.
+func (i *Identity) InGroup(group string) bool {
+	if i == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(i.Groups, func(g string) bool {
+		return strings.EqualFold(g, group)
+	})
+}
@@ -0,0 +1,146 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// jsonWebKey is a single key from a JWKS document. Only RSA and P-256 keys
+// are supported, which covers the ID tokens of common providers.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// publicKey converts the JWK to an *rsa.PublicKey or *ecdsa.PublicKey.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// parseJWT splits a compact JWT and decodes its header and claims without
+// verifying it.
+func parseJWT(token string, claims any) (jwtHeader, []byte, []byte, error) {
+	var header jwtHeader
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, nil, nil, errors.New("malformed token")
+	}
+
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return header, nil, nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return header, nil, nil, fmt.Errorf("malformed token header: %w", err)
+	}
+
+	claimsData, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return header, nil, nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := json.Unmarshal(claimsData, claims); err != nil {
+		return header, nil, nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return header, nil, nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+
+	return header, []byte(parts[0] + "." + parts[1]), signature, nil
+}
+
+// verifySignature checks a RS256 or ES256 signature over the signed part of a JWT.
+func verifySignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	digest := sha256.Sum256(signed)
+
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match algorithm RS256")
+		}
+
+		return rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature)
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("key or signature does not match algorithm ES256")
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
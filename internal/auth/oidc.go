@@ -0,0 +1,422 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// loginState is kept in a short-lived signed cookie between redirecting the
+// user to the provider and the provider redirecting back.
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Redirect string `json:"redirect"`
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// keyRefreshInterval is the shortest time between two fetches of the key set.
+const keyRefreshInterval = time.Minute
+
+// OIDCProvider implements the authorization code flow with PKCE against an
+// OpenID Connect provider. The discovery document and keys are fetched on
+// first use, so HomeDash can start while the provider is unavailable.
+type OIDCProvider struct {
+	Client *http.Client
+
+	mu          sync.Mutex
+	discovery   *discoveryDocument
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+	refreshing  chan struct{}
+}
+
+func NewOIDCProvider() *OIDCProvider {
+	return &OIDCProvider{
+		Client: &http.Client{Timeout: 10 * time.Second},
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+func (p *OIDCProvider) getDiscovery() (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(c.Config.Auth.OIDC.Issuer, "/")
+
+	var discovery discoveryDocument
+	if err := p.getJSON(issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover provider: %w", err)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("provider reports issuer %q instead of %q", discovery.Issuer, issuer)
+	}
+
+	p.discovery = &discovery
+
+	return p.discovery, nil
+}
+
+// getKey returns the key with the given id, refreshing the key set when the
+// key is unknown since providers rotate their keys. Anyone can send a token
+// with a made up key id, so the key set is refreshed at most once per
+// keyRefreshInterval. Concurrent lookups wait for a refresh in progress.
+func (p *OIDCProvider) getKey(jwksURI string, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	key, exists := p.keys[kid]
+	refreshing := p.refreshing
+	refresh := !exists && refreshing == nil && time.Since(p.keysFetched) >= keyRefreshInterval
+	if refresh {
+		refreshing = make(chan struct{})
+		p.refreshing = refreshing
+		p.keysFetched = time.Now()
+	}
+	p.mu.Unlock()
+
+	if exists {
+		return key, nil
+	}
+
+	// The keys are fetched without the lock held, so a slow provider doesn't
+	// block the lookup of known keys
+	if refresh {
+		keys, err := p.fetchKeys(jwksURI)
+
+		p.mu.Lock()
+		if err == nil {
+			p.keys = keys
+		}
+		p.refreshing = nil
+		p.mu.Unlock()
+		close(refreshing)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if refreshing != nil {
+		<-refreshing
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, exists = p.keys[kid]
+	if !exists {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+// fetchKeys returns the signing keys in the key set at jwksURI.
+func (p *OIDCProvider) fetchKeys(jwksURI string) (map[string]crypto.PublicKey, error) {
+	var keySet jsonWebKeySet
+	if err := p.getJSON(jwksURI, &keySet); err != nil {
+		return nil, fmt.Errorf("failed to fetch keys: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			c.Logger.Debug().Err(err).Str("kid", jwk.Kid).Msg("skipping unsupported key")
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (p *OIDCProvider) getJSON(url string, value any) error {
+	response, err := p.Client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", response.Status, url)
+	}
+
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+// Login redirects the user to the provider. After logging in, the user is sent
+// back to the redirect path.
+func (p *OIDCProvider) Login(w http.ResponseWriter, r *http.Request, redirect string) error {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return err
+	}
+
+	state := loginState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		Redirect: safeRedirect(redirect),
+	}
+
+	if err := setSignedCookie(w, r, loginCookie, state, time.Now().Add(10*time.Minute)); err != nil {
+		return err
+	}
+
+	challenge := sha256.Sum256([]byte(state.Verifier))
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.Config.Auth.OIDC.ClientID)
+	params.Set("redirect_uri", callbackURL(r))
+	params.Set("scope", strings.Join(c.Config.Auth.OIDC.Scopes, " "))
+	params.Set("state", state.State)
+	params.Set("nonce", state.Nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+
+	http.Redirect(w, r, discovery.AuthorizationEndpoint+"?"+params.Encode(), http.StatusFound)
+
+	return nil
+}
+
+// Callback finishes the login. It returns the identity of the user and the
+// path the user wanted to visit.
+func (p *OIDCProvider) Callback(w http.ResponseWriter, r *http.Request) (*Identity, string, error) {
+	var state loginState
+	if err := readSignedCookie(r, loginCookie, &state); err != nil {
+		return nil, "", fmt.Errorf("missing login state: %w", err)
+	}
+	clearCookie(w, r, loginCookie)
+
+	if errorCode := r.URL.Query().Get("error"); errorCode != "" {
+		return nil, "", fmt.Errorf("provider returned error %q: %s", errorCode, r.URL.Query().Get("error_description"))
+	}
+
+	if r.URL.Query().Get("state") != state.State {
+		return nil, "", errors.New("state does not match")
+	}
+
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, "", err
+	}
+
+	idToken, err := p.exchangeCode(discovery, r, state.Verifier)
+	if err != nil {
+		return nil, "", err
+	}
+
+	identity, err := p.verifyIDToken(discovery, idToken, state.Nonce)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return identity, state.Redirect, nil
+}
+
+// exchangeCode trades the authorization code for an ID token.
+func (p *OIDCProvider) exchangeCode(discovery *discoveryDocument, r *http.Request, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", r.URL.Query().Get("code"))
+	form.Set("redirect_uri", callbackURL(r))
+	form.Set("client_id", c.Config.Auth.OIDC.ClientID)
+	form.Set("code_verifier", verifier)
+	if c.Config.Auth.OIDC.ClientSecret != "" {
+		form.Set("client_secret", c.Config.Auth.OIDC.ClientSecret)
+	}
+
+	response, err := p.Client.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer response.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK || token.IDToken == "" {
+		return "", fmt.Errorf("token endpoint returned %s %s", response.Status, token.Error)
+	}
+
+	return token.IDToken, nil
+}
+
+// verifyIDToken checks the signature and claims of the ID token and turns it
+// into an identity.
+func (p *OIDCProvider) verifyIDToken(discovery *discoveryDocument, idToken string, nonce string) (*Identity, error) {
+	var claims map[string]any
+
+	header, signed, signature, err := parseJWT(idToken, &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := p.getKey(discovery.JwksURI, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, signed, signature); err != nil {
+		return nil, fmt.Errorf("invalid ID token signature: %w", err)
+	}
+
+	if claims["iss"] != discovery.Issuer {
+		return nil, fmt.Errorf("ID token issued by %v", claims["iss"])
+	}
+
+	if !audienceContains(claims["aud"], c.Config.Auth.OIDC.ClientID) {
+		return nil, errors.New("ID token is not meant for this client")
+	}
+
+	if exp, ok := claims["exp"].(float64); !ok || time.Now().Unix() > int64(exp) {
+		return nil, errors.New("ID token expired")
+	}
+
+	if claims["nonce"] != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	identity := &Identity{
+		User:   stringClaim(claims, "preferred_username"),
+		Email:  stringClaim(claims, "email"),
+		Groups: stringsClaim(claims, c.Config.Auth.OIDC.GroupsClaim),
+	}
+
+	if identity.User == "" {
+		identity.User = stringClaim(claims, "sub")
+	}
+
+	return identity, nil
+}
+
+func audienceContains(aud any, clientID string) bool {
+	switch audience := aud.(type) {
+	case string:
+		return audience == clientID
+	case []any:
+		return slices.Contains(audience, any(clientID))
+	}
+
+	return false
+}
+
+func stringClaim(claims map[string]any, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+func stringsClaim(claims map[string]any, name string) []string {
+	values := []string{}
+
+	switch claim := claims[name].(type) {
+	case string:
+		values = append(values, claim)
+	case []any:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	return values
+}
+
+// callbackURL returns the configured redirect URL. Without one, HomeDash is
+// not behind a proxy (see checkRedirectURL) and the URL is derived from the
+// request.
+func callbackURL(r *http.Request) string {
+	if c.Config.Auth.OIDC.RedirectURL != "" {
+		return c.Config.Auth.OIDC.RedirectURL
+	}
+
+	scheme := "http"
+	if isSecure(r) {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + "/auth/callback"
+}
+
+// checkRedirectURL makes sure the callback URL can be trusted. Behind a proxy
+// the requested host and scheme say little, so the URL must be configured.
+func checkRedirectURL() error {
+	redirectURL := c.Config.Auth.OIDC.RedirectURL
+	if redirectURL == "" {
+		if len(c.Config.Network.TrustedProxies) > 0 {
+			return errors.New("auth.oidc.redirecturl is required when running behind network.trustedproxies")
+		}
+		return nil
+	}
+
+	parsed, err := url.Parse(redirectURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid auth.oidc.redirecturl %q, expected a URL like https://dash.example.com/auth/callback", redirectURL)
+	}
+
+	return nil
+}
+
+// safeRedirect only allows local paths, so the login can't be abused to send
+// users to another site.
+func safeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.Contains(redirect, "\\") {
+		return "/"
+	}
+
+	return redirect
+}
+
+func randomString() string {
+	data := make([]byte, 32)
+	rand.Read(data)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
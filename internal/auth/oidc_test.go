@@ -0,0 +1,379 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/network"
+)
+
+func TestMain(m *testing.M) {
+	logger := zerolog.Nop()
+	c.Logger = &logger
+
+	os.Exit(m.Run())
+}
+
+// mockProvider is an OpenID Connect provider serving discovery, keys and a
+// token endpoint that returns idToken. keyRequests counts the fetches of the
+// key set.
+type mockProvider struct {
+	server      *httptest.Server
+	rsaKey      *rsa.PrivateKey
+	ecKey       *ecdsa.PrivateKey
+	idToken     string
+	keyRequests atomic.Int32
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	p := &mockProvider{}
+	var err error
+	if p.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if p.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JwksURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.keyRequests.Add(1)
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{
+			{
+				Kid: "rsa",
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(p.rsaKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.rsaKey.E)).Bytes()),
+			},
+			{
+				Kid: "ec",
+				Kty: "EC",
+				Crv: "P-256",
+				X:   base64.RawURLEncoding.EncodeToString(p.ecKey.X.FillBytes(make([]byte, 32))),
+				Y:   base64.RawURLEncoding.EncodeToString(p.ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "the-code" || r.FormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(tokenResponse{IDToken: p.idToken})
+	})
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	c.Config.Auth.OIDC = c.OIDCConfiguration{
+		Enabled:       true,
+		Issuer:        p.server.URL,
+		ClientID:      "homedash",
+		RedirectURL:   "https://dash.example.com/auth/callback",
+		Scopes:        []string{"openid"},
+		GroupsClaim:   "groups",
+		SessionSecret: "test",
+	}
+	SetupSessions()
+
+	return p
+}
+
+func (p *mockProvider) claims(nonce string) map[string]any {
+	return map[string]any{
+		"iss":                p.server.URL,
+		"aud":                "homedash",
+		"sub":                "1234",
+		"preferred_username": "john",
+		"groups":             []string{"admins"},
+		"exp":                time.Now().Add(5 * time.Minute).Unix(),
+		"nonce":              nonce,
+	}
+}
+
+// encodeJWT builds a compact JWT, signing it with the sign function.
+func encodeJWT(t *testing.T, header map[string]any, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsData, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(headerData) + "." + base64.RawURLEncoding.EncodeToString(claimsData)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newMockProvider(t)
+	provider := NewOIDCProvider()
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The RSA public key as an HMAC secret, for the classic RS256/HS256 confusion
+	publicKeyData, err := x509.MarshalPKIXPublicKey(&p.rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signHS256 := func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, publicKeyData)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+
+	with := func(change func(claims map[string]any)) map[string]any {
+		claims := p.claims("the-nonce")
+		change(claims)
+		return claims
+	}
+
+	// tamper replaces the claims of a token, keeping the signature
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		claimsData, err := json.Marshal(with(func(claims map[string]any) { claims["preferred_username"] = "admin" }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString(claimsData) + "." + parts[2]
+	}
+
+	rs256 := map[string]any{"alg": "RS256", "kid": "rsa"}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"valid RS256", encodeJWT(t, rs256, p.claims("the-nonce"), signRS256(t, p.rsaKey)), true},
+		{"valid ES256", encodeJWT(t, map[string]any{"alg": "ES256", "kid": "ec"}, p.claims("the-nonce"), signES256(t, p.ecKey)), true},
+		{"audience list", encodeJWT(t, rs256, with(func(claims map[string]any) { claims["aud"] = []string{"other", "homedash"} }), signRS256(t, p.rsaKey)), true},
+		{"bad signature", encodeJWT(t, rs256, p.claims("the-nonce"), signRS256(t, otherKey)), false},
+		{"tampered claims", tamper(encodeJWT(t, rs256, p.claims("the-nonce"), signRS256(t, p.rsaKey))), false},
+		{"unknown key", encodeJWT(t, map[string]any{"alg": "RS256", "kid": "other"}, p.claims("the-nonce"), signRS256(t, p.rsaKey)), false},
+		{"wrong issuer", encodeJWT(t, rs256, with(func(claims map[string]any) { claims["iss"] = "https://evil.example.com" }), signRS256(t, p.rsaKey)), false},
+		{"wrong audience", encodeJWT(t, rs256, with(func(claims map[string]any) { claims["aud"] = "other" }), signRS256(t, p.rsaKey)), false},
+		{"expired", encodeJWT(t, rs256, with(func(claims map[string]any) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }), signRS256(t, p.rsaKey)), false},
+		{"no expiry", encodeJWT(t, rs256, with(func(claims map[string]any) { delete(claims, "exp") }), signRS256(t, p.rsaKey)), false},
+		{"nonce mismatch", encodeJWT(t, rs256, p.claims("other-nonce"), signRS256(t, p.rsaKey)), false},
+		{"alg none", encodeJWT(t, map[string]any{"alg": "none", "kid": "rsa"}, p.claims("the-nonce"), func([]byte) []byte { return nil }), false},
+		{"alg HS256 with the public key", encodeJWT(t, map[string]any{"alg": "HS256", "kid": "rsa"}, p.claims("the-nonce"), signHS256), false},
+		{"alg ES256 with an RSA key", encodeJWT(t, map[string]any{"alg": "ES256", "kid": "rsa"}, p.claims("the-nonce"), signES256(t, p.ecKey)), false},
+		{"alg RS256 with an EC key", encodeJWT(t, map[string]any{"alg": "RS256", "kid": "ec"}, p.claims("the-nonce"), signRS256(t, p.rsaKey)), false},
+		{"malformed", "not-a-token", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			discovery, err := provider.getDiscovery()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			identity, err := provider.verifyIDToken(discovery, test.token, "the-nonce")
+			if test.valid {
+				if err != nil {
+					t.Fatalf("expected a valid token, got %v", err)
+				}
+				if identity.User != "john" || !identity.InGroup("admins") {
+					t.Errorf("unexpected identity %+v", identity)
+				}
+			} else if err == nil {
+				t.Fatal("expected the token to be rejected")
+			}
+		})
+	}
+}
+
+func TestGetKeyRateLimitsRefreshes(t *testing.T) {
+	p := newMockProvider(t)
+	provider := NewOIDCProvider()
+	jwksURI := p.server.URL + "/jwks"
+
+	if _, err := provider.getKey(jwksURI, "rsa"); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		if _, err := provider.getKey(jwksURI, "other"); err == nil {
+			t.Fatal("expected an unknown key to be rejected")
+		}
+	}
+	if requests := p.keyRequests.Load(); requests != 1 {
+		t.Errorf("expected unknown keys not to refresh the key set within a minute, got %d requests", requests)
+	}
+
+	provider.mu.Lock()
+	provider.keysFetched = time.Now().Add(-keyRefreshInterval)
+	provider.mu.Unlock()
+
+	if _, err := provider.getKey(jwksURI, "other"); err == nil {
+		t.Fatal("expected an unknown key to be rejected")
+	}
+	if _, err := provider.getKey(jwksURI, "ec"); err != nil {
+		t.Fatal(err)
+	}
+	if requests := p.keyRequests.Load(); requests != 2 {
+		t.Errorf("expected the key set to be refreshed after a minute, got %d requests", requests)
+	}
+}
+
+func TestGetKeyConcurrently(t *testing.T) {
+	p := newMockProvider(t)
+	provider := NewOIDCProvider()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := provider.getKey(p.server.URL+"/jwks", "rsa"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if requests := p.keyRequests.Load(); requests != 1 {
+		t.Errorf("expected concurrent lookups to share one fetch, got %d requests", requests)
+	}
+}
+
+func TestLoginCallback(t *testing.T) {
+	p := newMockProvider(t)
+	provider := NewOIDCProvider()
+
+	recorder := httptest.NewRecorder()
+	if err := provider.Login(recorder, httptest.NewRequest(http.MethodGet, "/auth/login", nil), "/d/media"); err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := location.Query()
+	if params.Get("redirect_uri") != c.Config.Auth.OIDC.RedirectURL {
+		t.Errorf("expected the configured redirect URL, got %s", params.Get("redirect_uri"))
+	}
+	if params.Get("code_challenge_method") != "S256" || params.Get("code_challenge") == "" {
+		t.Error("expected a PKCE challenge")
+	}
+
+	callback := func(state string, nonce string) (*Identity, string, error) {
+		p.idToken = encodeJWT(t, map[string]any{"alg": "RS256", "kid": "rsa"}, p.claims(nonce), signRS256(t, p.rsaKey))
+
+		request := httptest.NewRequest(http.MethodGet, "/auth/callback?code=the-code&state="+url.QueryEscape(state), nil)
+		for _, cookie := range recorder.Result().Cookies() {
+			request.AddCookie(cookie)
+		}
+
+		return provider.Callback(httptest.NewRecorder(), request)
+	}
+
+	if _, _, err := callback("wrong-state", params.Get("nonce")); err == nil {
+		t.Error("expected a state mismatch to be rejected")
+	}
+
+	if _, _, err := callback(params.Get("state"), "wrong-nonce"); err == nil {
+		t.Error("expected a nonce mismatch to be rejected")
+	}
+
+	identity, redirect, err := callback(params.Get("state"), params.Get("nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.User != "john" || redirect != "/d/media" {
+		t.Errorf("unexpected identity %+v or redirect %s", identity, redirect)
+	}
+}
+
+func TestIsSecureOnlyTrustsProxies(t *testing.T) {
+	network.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")}
+	t.Cleanup(func() { network.TrustedProxies = nil })
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Forwarded-Proto", "https")
+
+	request.RemoteAddr = "192.168.1.10:1234"
+	if isSecure(request) {
+		t.Error("X-Forwarded-Proto from an untrusted client was believed")
+	}
+
+	request.RemoteAddr = "10.0.0.1:1234"
+	if !isSecure(request) {
+		t.Error("X-Forwarded-Proto from a trusted proxy was ignored")
+	}
+}
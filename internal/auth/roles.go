@@ -0,0 +1,197 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// Roles grant access to parts of the API. Viewers may read the dashboard,
+// publishers (sidecars) may report applications and admins may do anything.
+const (
+	RoleViewer    = "viewer"
+	RolePublisher = "publisher"
+	RoleAdmin     = "admin"
+)
+
+// AssignRoles gives the identity the roles for authenticated users and the
+// roles mapped to its groups.
+func AssignRoles(identity *Identity) {
+	if identity == nil {
+		return
+	}
+
+	identity.Roles = []string{}
+	addRole := func(role string) {
+		if !slices.Contains(identity.Roles, role) {
+			identity.Roles = append(identity.Roles, role)
+		}
+	}
+
+	for _, role := range c.Config.Auth.AuthenticatedRoles {
+		addRole(role)
+	}
+
+	for _, mapping := range c.Config.Auth.Roles {
+		if identity.InGroup(mapping.Group) {
+			addRole(mapping.Role)
+		}
+	}
+
+	if c.Config.Auth.AdminGroup != "" && identity.InGroup(c.Config.Auth.AdminGroup) {
+		addRole(RoleAdmin)
+	}
+}
+
+// HasRole reports whether the identity was given the role.
+func (i *Identity) HasRole(role string) bool {
+	return i != nil && slices.Contains(i.Roles, role)
+}
+
+// AnonymousRoles returns the roles of anonymous users. Unless configured,
+// anonymous users may view and publish, and may administer HomeDash as long
+// as no authentication, admin group or role mapping is configured, like
+// HomeDash always did. With the built-in login, anonymous users may at most
+// view the dashboard, and only when configured to.
+func AnonymousRoles() []string {
+	if c.Config.Auth.OIDC.Enabled {
+		if slices.ContainsFunc(c.Config.Auth.AnonymousRoles, func(role string) bool {
+			return strings.EqualFold(role, RoleViewer)
+		}) {
+			return []string{RoleViewer}
+		}
+
+		return []string{}
+	}
+
+	if c.Config.Auth.AnonymousRoles != nil {
+		return c.Config.Auth.AnonymousRoles
+	}
+
+	roles := []string{RoleViewer, RolePublisher}
+	if !authEnabled() && c.Config.Auth.AdminGroup == "" && len(c.Config.Auth.Roles) == 0 && len(c.Config.Auth.APIKeys) == 0 {
+		roles = append(roles, RoleAdmin)
+	}
+
+	return roles
+}
+
+// authEnabled reports whether users can be identified, by a forward-auth
+// proxy or the built-in login.
+func authEnabled() bool {
+	return c.Config.Auth.ForwardAuth.Enabled || c.Config.Auth.OIDC.Enabled
+}
+
+// adminConfigured reports whether anyone can become an admin, through the
+// admin group, a role mapping or an API key.
+func adminConfigured() bool {
+	if c.Config.Auth.AdminGroup != "" {
+		return true
+	}
+
+	for _, mapping := range c.Config.Auth.Roles {
+		if strings.EqualFold(mapping.Role, RoleAdmin) {
+			return true
+		}
+	}
+
+	for _, apiKey := range c.Config.Auth.APIKeys {
+		if strings.EqualFold(apiKey.Role, RoleAdmin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize reports whether the identity, or an anonymous user when identity
+// is nil, has the role. Admins are authorized for every role and everyone has
+// at least the roles of anonymous users.
+func Authorize(identity *Identity, role string) bool {
+	if identity.HasRole(role) || identity.HasRole(RoleAdmin) {
+		return true
+	}
+
+	anonymousRoles := AnonymousRoles()
+
+	return slices.Contains(anonymousRoles, role) || slices.Contains(anonymousRoles, RoleAdmin)
+}
+
+// IsAdmin reports whether the identity may use the admin API.
+func IsAdmin(identity *Identity) bool {
+	return Authorize(identity, RoleAdmin)
+}
+
+// ValidRole reports whether the role is one HomeDash knows.
+func ValidRole(role string) bool {
+	return slices.Contains([]string{RoleViewer, RolePublisher, RoleAdmin}, strings.ToLower(role))
+}
+
+func normalizeRoles(roles []string) {
+	for i := range roles {
+		roles[i] = strings.ToLower(roles[i])
+	}
+}
+
+// Setup checks the configured roles and API keys and prepares sessions. Roles
+// are lowercased, so "Admin" in the config grants the admin role.
+func Setup() error {
+	normalizeRoles(c.Config.Auth.AnonymousRoles)
+	normalizeRoles(c.Config.Auth.AuthenticatedRoles)
+	for i := range c.Config.Auth.Roles {
+		c.Config.Auth.Roles[i].Role = strings.ToLower(c.Config.Auth.Roles[i].Role)
+	}
+	for i := range c.Config.Auth.APIKeys {
+		c.Config.Auth.APIKeys[i].Role = strings.ToLower(c.Config.Auth.APIKeys[i].Role)
+	}
+
+	roles := append(slices.Clone(c.Config.Auth.AnonymousRoles), c.Config.Auth.AuthenticatedRoles...)
+	for _, mapping := range c.Config.Auth.Roles {
+		roles = append(roles, mapping.Role)
+	}
+
+	for _, apiKey := range c.Config.Auth.APIKeys {
+		if apiKey.Key == "" {
+			return fmt.Errorf("API key %q has no key", apiKey.Name)
+		}
+		roles = append(roles, apiKey.Role)
+	}
+
+	for _, role := range roles {
+		if !ValidRole(role) {
+			return fmt.Errorf("unknown role %q, expected viewer, publisher or admin", role)
+		}
+	}
+
+	if c.Config.Auth.OIDC.Enabled && slices.ContainsFunc(c.Config.Auth.AnonymousRoles, func(role string) bool {
+		return !strings.EqualFold(role, RoleViewer)
+	}) {
+		c.Logger.Warn().Msg("anonymous users can at most be viewers when oidc is enabled, ignoring their other roles")
+	}
+
+	if authEnabled() && !adminConfigured() && !slices.Contains(AnonymousRoles(), RoleAdmin) {
+		c.Logger.Warn().Msg("no admin group or admin role configured, the admin API is disabled")
+	}
+
+	if c.Config.Auth.OIDC.Enabled {
+		if err := checkRedirectURL(); err != nil {
+			return err
+		}
+		SetupSessions()
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"slices"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// withAuthConfig sets the auth configuration for the duration of a test.
+func withAuthConfig(t *testing.T, config c.AuthConfiguration) {
+	previous := c.Config.Auth
+	c.Config.Auth = config
+	t.Cleanup(func() { c.Config.Auth = previous })
+}
+
+func TestSetupLowercasesRoles(t *testing.T) {
+	withAuthConfig(t, c.AuthConfiguration{
+		Roles:              []c.RoleMapping{{Group: "admins", Role: "Admin"}},
+		AnonymousRoles:     []string{"Viewer"},
+		AuthenticatedRoles: []string{"VIEWER"},
+		APIKeys:            []c.APIKey{{Name: "sidecar", Key: "secret", Role: "Publisher"}},
+	})
+
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	identity := &Identity{User: "john", Groups: []string{"admins"}}
+	AssignRoles(identity)
+	if !identity.HasRole(RoleAdmin) || !identity.HasRole(RoleViewer) {
+		t.Errorf("expected the admin and viewer roles, got %v", identity.Roles)
+	}
+
+	if !Authorize(nil, RoleViewer) || Authorize(nil, RolePublisher) {
+		t.Errorf("expected anonymous users to only view, got %v", AnonymousRoles())
+	}
+
+	if c.Config.Auth.APIKeys[0].Role != RolePublisher {
+		t.Errorf("expected the API key role to be lowercased, got %s", c.Config.Auth.APIKeys[0].Role)
+	}
+}
+
+func TestAnonymousRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		config c.AuthConfiguration
+		roles  []string
+	}{
+		{"no authentication", c.AuthConfiguration{}, []string{RoleViewer, RolePublisher, RoleAdmin}},
+		{"admin group", c.AuthConfiguration{AdminGroup: "admins"}, []string{RoleViewer, RolePublisher}},
+		{"forward-auth", c.AuthConfiguration{ForwardAuth: c.ForwardAuthConfiguration{Enabled: true}}, []string{RoleViewer, RolePublisher}},
+		{"oidc", c.AuthConfiguration{OIDC: c.OIDCConfiguration{Enabled: true}}, []string{}},
+		{"oidc with anonymous viewers", c.AuthConfiguration{
+			AnonymousRoles: []string{RoleViewer, RoleAdmin},
+			OIDC:           c.OIDCConfiguration{Enabled: true},
+		}, []string{RoleViewer}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withAuthConfig(t, test.config)
+
+			if roles := AnonymousRoles(); !slices.Equal(roles, test.roles) {
+				t.Errorf("expected %v, got %v", test.roles, roles)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/network"
+)
+
+const (
+	SessionCookie = "homedash_session"
+	loginCookie   = "homedash_login"
+)
+
+var (
+	ErrInvalidCookie = errors.New("invalid or expired cookie")
+
+	sessionKey []byte
+)
+
+// Session is stored in a signed cookie, so it must stay small.
+type Session struct {
+	Identity
+	Expires int64 `json:"exp"`
+}
+
+// SetupSessions prepares the key used to sign cookies. Without a configured
+// secret a random key is used, which means sessions don't survive a restart.
+func SetupSessions() {
+	if secret := c.Config.Auth.OIDC.SessionSecret; secret != "" {
+		sum := sha256.Sum256([]byte(secret))
+		sessionKey = sum[:]
+		return
+	}
+
+	c.Logger.Warn().Msg("no session secret configured, sessions will not survive a restart")
+	sessionKey = make([]byte, 32)
+	rand.Read(sessionKey)
+}
+
+// sign returns the value as base64 with an HMAC signature appended.
+func sign(value []byte) string {
+	mac := hmac.New(sha256.New, sessionKey)
+	mac.Write(value)
+
+	return base64.RawURLEncoding.EncodeToString(value) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature of a signed value and returns the value.
+func verify(signed string) ([]byte, error) {
+	encodedValue, encodedSignature, found := strings.Cut(signed, ".")
+	if !found {
+		return nil, ErrInvalidCookie
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	mac := hmac.New(sha256.New, sessionKey)
+	mac.Write(value)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidCookie
+	}
+
+	return value, nil
+}
+
+// setSignedCookie stores the value as JSON in a signed cookie.
+func setSignedCookie(w http.ResponseWriter, r *http.Request, name string, value any, expires time.Time) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    sign(data),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// readSignedCookie reads a cookie written by setSignedCookie into value.
+func readSignedCookie(r *http.Request, name string, value any) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := verify(cookie.Value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+func clearCookie(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// StartSession stores the identity in the session cookie.
+func StartSession(w http.ResponseWriter, r *http.Request, identity *Identity) error {
+	expires := time.Now().Add(time.Duration(c.Config.Auth.OIDC.SessionDuration) * time.Minute)
+
+	session := Session{Identity: *identity, Expires: expires.Unix()}
+	session.Roles = nil
+
+	return setSignedCookie(w, r, SessionCookie, session, expires)
+}
+
+// SessionIdentity returns the identity from a valid session cookie, or nil.
+func SessionIdentity(r *http.Request) *Identity {
+	var session Session
+	if err := readSignedCookie(r, SessionCookie, &session); err != nil {
+		return nil
+	}
+
+	if time.Now().Unix() > session.Expires {
+		return nil
+	}
+
+	// Roles are assigned on every request, so changes to the mapping apply
+	// to existing sessions.
+	AssignRoles(&session.Identity)
+
+	return &session.Identity
+}
+
+// EndSession removes the session cookie.
+func EndSession(w http.ResponseWriter, r *http.Request) {
+	clearCookie(w, r, SessionCookie)
+}
+
+// isSecure reports whether the client connected using HTTPS, either directly
+// or through a trusted proxy.
+func isSecure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+
+	return network.IsTrustedProxy(r) && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
@@ -32,15 +32,85 @@ type Configuration struct {
 	MaxAgeBeforeCleanup int  `koanf:"maxage"`
 	CleanCheckInterval  int  `koanf:"cleaninterval"`
 
-	Cors   CorsConfiguration   `koanf:"cors"`
-	Icons  IconConfiguration   `koanf:"icons"`
-	Static StaticConfiguration `koanf:"static"`
-	Server ServerConfiguration `koanf:"server"`
+	API        APIConfiguration     `koanf:"api"`
+	Auth       AuthConfiguration    `koanf:"auth"`
+	Cors       CorsConfiguration    `koanf:"cors"`
+	Icons      IconConfiguration    `koanf:"icons"`
+	Merge      MergeConfiguration   `koanf:"merge"`
+	Network    NetworkConfiguration `koanf:"network"`
+	Groups     []m.Group            `koanf:"groups"`
+	Dashboards []m.Dashboard        `koanf:"dashboards"`
+	Sources    SourcesConfiguration `koanf:"sources"`
+	Static     StaticConfiguration  `koanf:"static"`
+	Server     ServerConfiguration  `koanf:"server"`
 }
 
 type ServerConfiguration struct {
-	Address string `koanf:"address"`
-	Port    string `koanf:"port"`
+	Address string            `koanf:"address"`
+	Port    string            `koanf:"port"`
+	MDNS    MDNSConfiguration `koanf:"mdns"`
+}
+
+// MDNSConfiguration advertises HomeDash on the local network as an _http._tcp
+// service called Name.
+type MDNSConfiguration struct {
+	Advertise bool   `koanf:"advertise"`
+	Name      string `koanf:"name"`
+	Interface string `koanf:"interface"`
+}
+
+type APIConfiguration struct {
+	MaxBodySize       int64    `koanf:"maxbodysize"`
+	MaxAppsPerSidecar int      `koanf:"maxappspersidecar"`
+	AllowedSchemes    []string `koanf:"allowedschemes"`
+}
+
+// AuthConfiguration determines who may do what. Anonymous users get
+// AnonymousRoles, authenticated users get AuthenticatedRoles plus the roles
+// mapped to their groups and API keys get their own role.
+type AuthConfiguration struct {
+	AdminGroup         string                   `koanf:"admingroup"`
+	Roles              []RoleMapping            `koanf:"roles"`
+	AnonymousRoles     []string                 `koanf:"anonymousroles"`
+	AuthenticatedRoles []string                 `koanf:"authenticatedroles"`
+	APIKeys            []APIKey                 `koanf:"apikeys"`
+	ForwardAuth        ForwardAuthConfiguration `koanf:"forwardauth"`
+	OIDC               OIDCConfiguration        `koanf:"oidc"`
+}
+
+type APIKey struct {
+	Name string `koanf:"name"`
+	Key  string `koanf:"key"`
+	Role string `koanf:"role"`
+}
+
+// RoleMapping gives members of Group the Role.
+type RoleMapping struct {
+	Group string `koanf:"group"`
+	Role  string `koanf:"role"`
+}
+
+// OIDCConfiguration enables the built-in OpenID Connect login. Sessions are
+// kept in cookies signed with SessionSecret and last SessionDuration minutes.
+type OIDCConfiguration struct {
+	Enabled         bool     `koanf:"enabled"`
+	Issuer          string   `koanf:"issuer"`
+	ClientID        string   `koanf:"clientid"`
+	ClientSecret    string   `koanf:"clientsecret"`
+	RedirectURL     string   `koanf:"redirecturl"`
+	Scopes          []string `koanf:"scopes"`
+	GroupsClaim     string   `koanf:"groupsclaim"`
+	SessionSecret   string   `koanf:"sessionsecret"`
+	SessionDuration int      `koanf:"sessionduration"`
+}
+
+// ForwardAuthConfiguration describes the identity headers set by a
+// forward-auth proxy. They are only read from network.trustedproxies.
+type ForwardAuthConfiguration struct {
+	Enabled      bool   `koanf:"enabled"`
+	UserHeader   string `koanf:"userheader"`
+	GroupsHeader string `koanf:"groupsheader"`
+	EmailHeader  string `koanf:"emailheader"`
 }
 
 type IconConfiguration struct {
@@ -48,8 +118,30 @@ type IconConfiguration struct {
 	TmpDir   string `koanf:"tmpdir"`
 }
 
+// MergeConfiguration determines how duplicate applications reported by
+// different sources are combined. Duplicates are detected using Key ("url" or
+// "name") and resolved using Policy ("keep-all", "prefer-static" or
+// "prefer-newest").
+type MergeConfiguration struct {
+	Policy string `koanf:"policy"`
+	Key    string `koanf:"key"`
+}
+
+// NetworkConfiguration describes the networks HomeDash is used from. Clients
+// on InternalNetworks get the internal url of applications, others get the
+// external url. X-Forwarded-For is only believed from TrustedProxies.
+type NetworkConfiguration struct {
+	TrustedProxies   []string `koanf:"trustedproxies"`
+	InternalNetworks []string `koanf:"internalnetworks"`
+}
+
+// StaticConfiguration holds the statically defined applications. Apps
+// managed through the admin API are kept in StoreFile instead, and AppsDir
+// can hold more apps and groups as separate files.
 type StaticConfiguration struct {
-	Apps []m.ContainerInfo `koanf:"apps"`
+	Apps      []m.ContainerInfo `koanf:"apps"`
+	StoreFile string            `koanf:"storefile"`
+	AppsDir   string            `koanf:"appsdir"`
 }
 
 type CorsConfiguration struct {
@@ -76,20 +168,43 @@ func initConfig() {
 	k.Set("checkInterval", 1)
 	k.Set("server.address", "")
 	k.Set("server.port", "8080")
+	k.Set("server.mdns.advertise", false)
+	k.Set("server.mdns.name", "HomeDash")
+	k.Set("api.maxbodysize", 1024*1024)
+	k.Set("api.maxappspersidecar", 250)
+	k.Set("api.allowedschemes", []string{"http", "https"})
+	k.Set("auth.admingroup", "")
+	k.Set("auth.authenticatedroles", []string{"viewer"})
+	k.Set("auth.forwardauth.enabled", false)
+	k.Set("auth.forwardauth.userheader", "Remote-User")
+	k.Set("auth.forwardauth.groupsheader", "Remote-Groups")
+	k.Set("auth.forwardauth.emailheader", "Remote-Email")
+	k.Set("auth.oidc.enabled", false)
+	k.Set("auth.oidc.scopes", []string{"openid", "profile", "email"})
+	k.Set("auth.oidc.groupsclaim", "groups")
+	k.Set("auth.oidc.sessionduration", 12*60)
 	k.Set("cors.allowedOrigins", "*")
 	k.Set("cors.allowCredentials", false)
 	k.Set("cors.allowedHeaders", "Content-Type")
 	k.Set("cors.allowedMethods", allowedMethods)
 	k.Set("cors.debug", false)
+	k.Set("merge.policy", "keep-all")
+	k.Set("merge.key", "url")
+	k.Set("network.trustedproxies", []string{})
+	k.Set("network.internalnetworks", []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.0/8", "fc00::/7", "::1/128"})
 	k.Set("apps", []m.ContainerInfo{})
+	k.Set("groups", []m.Group{})
+	k.Set("dashboards", []m.Dashboard{})
 
 	if hasContainerDataDir() {
 		Logger.Debug().Msg("detected default /homedash directory, using container-optimized paths")
 		k.Set("icons.tmpDir", "/homedash/tmp")
 		k.Set("icons.cacheDir", "/homedash/cache")
+		k.Set("static.storefile", "/homedash/apps.json")
 	} else {
 		k.Set("icons.tmpDir", "./data/tmp")
 		k.Set("icons.cacheDir", "./data/cache")
+		k.Set("static.storefile", "./data/apps.json")
 	}
 
 	// Load Config File
@@ -118,6 +233,8 @@ func initConfig() {
 		Logger.Fatal().Err(err).Msg("failed to unmarshal configuration")
 	}
 
+	validateMergeConfig()
+
 	// Post-processing:	handle logic that depends on runtime state (like icon paths).
 	UpdateIconPaths()
 
@@ -159,6 +276,22 @@ func Setup() {
 	Logger.Debug().Interface("config", Config).Msg("dumping active configuration")
 }
 
+func validateMergeConfig() {
+	switch Config.Merge.Policy {
+	case "keep-all", "prefer-static", "prefer-newest":
+	default:
+		Logger.Warn().Str("policy", Config.Merge.Policy).Msg("unknown merge policy, falling back to keep-all")
+		Config.Merge.Policy = "keep-all"
+	}
+
+	switch Config.Merge.Key {
+	case "url", "name":
+	default:
+		Logger.Warn().Str("key", Config.Merge.Key).Msg("unknown merge key, falling back to url")
+		Config.Merge.Key = "url"
+	}
+}
+
 func hasContainerDataDir() bool {
 	if _, err := os.Stat("/homedash"); err != nil {
 		return false
@@ -174,7 +307,9 @@ func UpdateIconPaths() {
 
 func GetIconPath(icon string) string {
 	Logger.Debug().Str("icon", icon).Msg("getting path")
+	indexMu.RLock()
 	value, exists := Index[icon]
+	indexMu.RUnlock()
 
 	if !exists {
 		Logger.Debug().Str("icon", icon).Msg("not found in index")
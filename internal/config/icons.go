@@ -18,7 +18,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 )
 
 type App struct {
@@ -35,6 +37,12 @@ type AppList struct {
 	Apps     []App `json:"apps"`
 }
 
+var (
+	// indexMu guards Index, updateMu makes sure only one update runs at a time.
+	indexMu  sync.RWMutex
+	updateMu sync.Mutex
+)
+
 const (
 	zipURL      = "https://github.com/linuxserver/Heimdall-Apps/archive/refs/heads/gh-pages.zip"
 	zipFileName = "gh-pages.zip"
@@ -105,6 +113,9 @@ func unzipFile(src, dest string) error {
 }
 
 func UpdateIcons(refresh bool) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
 	_, err := os.Stat(filepath.Join(Config.Icons.CacheDir, "applications_index.json"))
 	if err == nil && !refresh {
 		Logger.Info().Msg("already have icons and not asked to refresh")
@@ -161,12 +172,14 @@ func createIndex() {
 		return
 	}
 
+	indexMu.Lock()
 	for i := range appList.Apps {
 		app := &appList.Apps[i]
 		app.IconName = strings.Split(app.Icon, ".")[0]
 
 		Index[app.IconName] = app.Icon
 	}
+	indexMu.Unlock()
 
 	updatedData, err := json.MarshalIndent(appList, "", "  ")
 	if err != nil {
@@ -199,11 +212,53 @@ func createIndexFromCache() {
 		return
 	}
 
+	indexMu.Lock()
 	for i := range appList.Apps {
 		app := &appList.Apps[i]
 
 		Index[app.IconName] = app.Icon
 	}
+	indexMu.Unlock()
 
 	Logger.Info().Msg("successfully read icon index from file.")
 }
+
+// SearchIcons returns up to limit icon names from the index that contain the
+// query, sorted by name.
+func SearchIcons(query string, limit int) []string {
+	query = strings.ToLower(query)
+
+	indexMu.RLock()
+	names := []string{}
+	for name := range Index {
+		if strings.Contains(strings.ToLower(name), query) {
+			names = append(names, name)
+		}
+	}
+	indexMu.RUnlock()
+
+	slices.Sort(names)
+	if len(names) > limit {
+		names = names[:limit]
+	}
+
+	return names
+}
+
+// GuessIcon returns the first of the names that is in the icon index, or an
+// empty string. Names are compared in lower case, with and without dashes.
+func GuessIcon(names ...string) string {
+	indexMu.RLock()
+	defer indexMu.RUnlock()
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, candidate := range []string{name, strings.ReplaceAll(name, "-", ""), strings.ReplaceAll(name, "_", "")} {
+			if _, exists := Index[candidate]; exists && candidate != "" {
+				return candidate
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,142 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package config
+
+// SourcesConfiguration enables discovery backends next to the sidecars and the
+// static applications. Every backend can be configured more than once, for
+// example for multiple clusters.
+type SourcesConfiguration struct {
+	Kubernetes []KubernetesSourceConfiguration `koanf:"kubernetes"`
+	Traefik    []TraefikSourceConfiguration    `koanf:"traefik"`
+	Caddy      []ConfigFileSourceConfiguration `koanf:"caddy"`
+	Nginx      []ConfigFileSourceConfiguration `koanf:"nginx"`
+	Consul     []ConsulSourceConfiguration     `koanf:"consul"`
+	Nomad      []NomadSourceConfiguration      `koanf:"nomad"`
+	MDNS       []MDNSSourceConfiguration       `koanf:"mdns"`
+	Proxmox    []ProxmoxSourceConfiguration    `koanf:"proxmox"`
+	Prometheus []PrometheusSourceConfiguration `koanf:"prometheus"`
+	Compose    []ConfigFileSourceConfiguration `koanf:"compose"`
+	Upstream   []UpstreamSourceConfiguration   `koanf:"upstream"`
+}
+
+// KubernetesSourceConfiguration discovers applications from Ingress and
+// HTTPRoute objects with homedash.* annotations. Without a Kubeconfig, the
+// in-cluster service account is used.
+type KubernetesSourceConfiguration struct {
+	Name            string   `koanf:"name"`
+	Kubeconfig      string   `koanf:"kubeconfig"`
+	Context         string   `koanf:"context"`
+	Namespaces      []string `koanf:"namespaces"`
+	Resources       []string `koanf:"resources"`
+	All             bool     `koanf:"all"`
+	HTTPRouteScheme string   `koanf:"httproutescheme"`
+}
+
+// TraefikSourceConfiguration discovers applications from the routers of a
+// Traefik instance, using its API. Interval is in seconds.
+type TraefikSourceConfiguration struct {
+	Name        string   `koanf:"name"`
+	Url         string   `koanf:"url"`
+	Username    string   `koanf:"username"`
+	Password    string   `koanf:"password"`
+	Interval    int      `koanf:"interval"`
+	EntryPoints []string `koanf:"entrypoints"`
+	Providers   []string `koanf:"providers"`
+	Routers     string   `koanf:"routers"`
+}
+
+// ConfigFileSourceConfiguration discovers applications from config files. The
+// paths can be files, directories or glob patterns.
+type ConfigFileSourceConfiguration struct {
+	Name  string   `koanf:"name"`
+	Paths []string `koanf:"paths"`
+}
+
+// ConsulSourceConfiguration discovers applications from the services in a
+// Consul catalog with homedash.* tags or homedash_* meta keys. Changes are
+// picked up through blocking queries, Interval is the longest time in seconds
+// a query waits.
+type ConsulSourceConfiguration struct {
+	Name       string `koanf:"name"`
+	Url        string `koanf:"url"`
+	Token      string `koanf:"token"`
+	Datacenter string `koanf:"datacenter"`
+	All        bool   `koanf:"all"`
+	Interval   int    `koanf:"interval"`
+}
+
+// NomadSourceConfiguration discovers applications from the services
+// registered in Nomad with homedash.* tags. Changes are picked up through
+// blocking queries, Interval is the longest time in seconds a query waits.
+type NomadSourceConfiguration struct {
+	Name      string `koanf:"name"`
+	Url       string `koanf:"url"`
+	Token     string `koanf:"token"`
+	Namespace string `koanf:"namespace"`
+	All       bool   `koanf:"all"`
+	Interval  int    `koanf:"interval"`
+}
+
+// MDNSSourceConfiguration discovers services advertised over mDNS on the local
+// network. Allow and Deny are glob patterns for instance names, Interval is
+// in seconds.
+type MDNSSourceConfiguration struct {
+	Name      string   `koanf:"name"`
+	Services  []string `koanf:"services"`
+	Allow     []string `koanf:"allow"`
+	Deny      []string `koanf:"deny"`
+	Interface string   `koanf:"interface"`
+	Addresses bool     `koanf:"addresses"`
+	Interval  int      `koanf:"interval"`
+}
+
+// ProxmoxSourceConfiguration discovers applications from the VMs and LXC
+// containers of Proxmox VE, using an API token like homedash@pve!dashboard.
+// Interval is in seconds.
+type ProxmoxSourceConfiguration struct {
+	Name     string   `koanf:"name"`
+	Url      string   `koanf:"url"`
+	TokenID  string   `koanf:"tokenid"`
+	Secret   string   `koanf:"secret"`
+	CAFile   string   `koanf:"cafile"`
+	Insecure bool     `koanf:"insecure"`
+	Nodes    []string `koanf:"nodes"`
+	All      bool     `koanf:"all"`
+	Interval int      `koanf:"interval"`
+}
+
+// PrometheusSourceConfiguration discovers applications from Prometheus file_sd
+// target files in Paths, or from the HTTP SD endpoint or Prometheus targets
+// API at Url. Interval is in seconds and only used for the endpoint.
+type PrometheusSourceConfiguration struct {
+	Name     string   `koanf:"name"`
+	Paths    []string `koanf:"paths"`
+	Url      string   `koanf:"url"`
+	Username string   `koanf:"username"`
+	Password string   `koanf:"password"`
+	All      bool     `koanf:"all"`
+	Interval int      `koanf:"interval"`
+}
+
+// UpstreamSourceConfiguration aggregates the applications of another HomeDash
+// instance at Url. Site labels its applications and defaults to the Name.
+// Grouping is "site" to put them in a group named after the site or "prefix"
+// to prefix their own groups with it. Interval is the longest time in seconds
+// a request waits for changes.
+type UpstreamSourceConfiguration struct {
+	Name     string `koanf:"name"`
+	Site     string `koanf:"site"`
+	Url      string `koanf:"url"`
+	APIKey   string `koanf:"apikey"`
+	Grouping string `koanf:"grouping"`
+	Interval int    `koanf:"interval"`
+}
@@ -0,0 +1,57 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import "go.yaml.in/yaml/v3"
+
+type dashyConfig struct {
+	Sections []struct {
+		Name  string `yaml:"name"`
+		Items []struct {
+			Title       string   `yaml:"title"`
+			Description string   `yaml:"description"`
+			Url         string   `yaml:"url"`
+			Icon        string   `yaml:"icon"`
+			Target      string   `yaml:"target"`
+			Tags        []string `yaml:"tags"`
+		} `yaml:"items"`
+	} `yaml:"sections"`
+}
+
+// convertDashy reads a Dashy conf.yml. Every section becomes a group.
+func convertDashy(root *yaml.Node, result *Result) error {
+	config := dashyConfig{}
+	if err := root.Decode(&config); err != nil {
+		return err
+	}
+
+	for _, section := range config.Sections {
+		for _, link := range section.Items {
+			// Dashy fetches the favicon of the site itself
+			icon := link.Icon
+			if icon == "favicon" || icon == "generative" {
+				icon = ""
+			}
+
+			result.add(section.Name, item{
+				Name:    link.Title,
+				Url:     link.Url,
+				Icon:    icon,
+				Comment: link.Description,
+				Target:  mapTarget(link.Target),
+				Tags:    link.Tags,
+			})
+		}
+	}
+
+	return nil
+}
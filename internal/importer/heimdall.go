@@ -0,0 +1,148 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// heimdallTag is the type of the items that are tags, which Heimdall shows as
+// folders of other items.
+const heimdallTag = 1
+
+// heimdallItem is a row of Heimdall's items table, as exported by
+// sqlite3 -json, or an item of Heimdall's own JSON export. The description
+// column holds the settings of enhanced apps, appdescription the text shown.
+type heimdallItem struct {
+	ID             int64   `yaml:"id"`
+	Title          string  `yaml:"title"`
+	Url            string  `yaml:"url"`
+	Icon           string  `yaml:"icon"`
+	AppDescription string  `yaml:"appdescription"`
+	Class          string  `yaml:"class"`
+	Type           int64   `yaml:"type"`
+	DeletedAt      *string `yaml:"deleted_at"`
+}
+
+// convertHeimdall reads exported Heimdall items. An export of the items table
+// doesn't say which tags the items are in, so they are imported without.
+func convertHeimdall(root *yaml.Node, result *Result) error {
+	items := []heimdallItem{}
+	if err := root.Decode(&items); err != nil {
+		return err
+	}
+
+	addHeimdallItems(items, nil, result)
+
+	return nil
+}
+
+// convertHeimdallDatabase reads the items of Heimdall's app.sqlite, with the
+// first tag of each item as its group.
+func convertHeimdallDatabase(data []byte, result *Result) error {
+	db, err := openSQLite(data)
+	if err != nil {
+		return err
+	}
+	if db.walMode() {
+		result.Unmapped = append(result.Unmapped, UnmappedItem{Item: "app.sqlite", Reason: "the database uses a write-ahead log, changes still in app.sqlite-wal are not imported"})
+	}
+
+	rows, err := db.table("items")
+	if err != nil {
+		return err
+	}
+
+	items := []heimdallItem{}
+	for _, row := range rows {
+		link := heimdallItem{
+			Title:          asString(row["title"]),
+			Url:            asString(row["url"]),
+			Icon:           asString(row["icon"]),
+			AppDescription: asString(row["appdescription"]),
+			Class:          asString(row["class"]),
+		}
+		link.ID, _ = row["id"].(int64)
+		link.Type, _ = row["type"].(int64)
+		if row["deleted_at"] != nil {
+			deletedAt := asString(row["deleted_at"])
+			link.DeletedAt = &deletedAt
+		}
+		items = append(items, link)
+	}
+
+	// The item_tag table links items to tags, tag 0 is the home dashboard
+	pivot, err := db.table("item_tag")
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(pivot, func(a, b map[string]any) int {
+		tagA, _ := a["tag_id"].(int64)
+		tagB, _ := b["tag_id"].(int64)
+		return cmp.Compare(tagA, tagB)
+	})
+
+	titles := map[int64]string{}
+	for _, link := range items {
+		if link.Type == heimdallTag && link.DeletedAt == nil {
+			titles[link.ID] = strings.TrimSpace(link.Title)
+		}
+	}
+
+	tags := map[int64][]string{}
+	for _, row := range pivot {
+		itemID, _ := row["item_id"].(int64)
+		tagID, _ := row["tag_id"].(int64)
+		if title := titles[tagID]; title != "" {
+			tags[itemID] = append(tags[itemID], title)
+		}
+	}
+
+	addHeimdallItems(items, tags, result)
+
+	return nil
+}
+
+// addHeimdallItems adds the items that aren't tags or deleted, with their tags
+// by item ID.
+func addHeimdallItems(items []heimdallItem, tags map[int64][]string, result *Result) {
+	for _, link := range items {
+		if link.DeletedAt != nil || link.Type == heimdallTag {
+			continue
+		}
+
+		// Items of supported apps have a class like
+		// App\SupportedApps\Jellyfin\Jellyfin, which names the app better than
+		// the uploaded icon file
+		icon := link.Icon
+		if link.Class != "" {
+			icon = link.Class[strings.LastIndex(link.Class, "\\")+1:]
+		}
+
+		group := ""
+		if itemTags := tags[link.ID]; len(itemTags) > 0 {
+			group = itemTags[0]
+		}
+
+		result.add(group, item{
+			Name:        link.Title,
+			Url:         link.Url,
+			Icon:        icon,
+			Description: link.AppDescription,
+			Tags:        tags[link.ID],
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// withIcons sets the icon index for the duration of a test.
+func withIcons(t *testing.T, icons c.IconIndex) {
+	previous := c.Index
+	c.Index = icons
+	t.Cleanup(func() { c.Index = previous })
+}
+
+// The fixture is an app.sqlite with Heimdall's schema and small pages, so the
+// items span several pages and the settings of Jellyfin overflow. Some items
+// were added before the appdescription column was.
+func TestConvertHeimdallDatabase(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg", "grafana": "grafana.svg"})
+
+	data, err := os.ReadFile("testdata/heimdall/app.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Convert("", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != FormatHeimdall {
+		t.Errorf("expected the heimdall format, got %s", result.Format)
+	}
+	if len(result.Apps) != 62 {
+		t.Fatalf("expected 62 applications, got %d", len(result.Apps))
+	}
+
+	jellyfin := result.Apps[0]
+	if jellyfin.Name != "Jellyfin" || jellyfin.Url != "https://jellyfin.example.com" || jellyfin.Icon != "jellyfin" ||
+		jellyfin.Group != "Media" || jellyfin.Description != "" {
+		t.Errorf("unexpected Jellyfin application %+v", jellyfin)
+	}
+
+	grafana := result.Apps[1]
+	if grafana.Group != "Media" || !slices.Equal(grafana.Tags, []string{"Media", "Monitoring"}) || grafana.Description != "Dashboards and alerts" {
+		t.Errorf("unexpected Grafana application %+v", grafana)
+	}
+
+	for i, app := range result.Apps[2:] {
+		name, url := fmt.Sprintf("Service %02d", i+1), fmt.Sprintf("https://service%02d.lan", i+1)
+		if app.Name != name || app.Url != url || app.Group != "" {
+			t.Errorf("expected %s at %s without a group, got %+v", name, url, app)
+		}
+	}
+
+	if len(result.Groups) != 1 || result.Groups[0].Name != "Media" {
+		t.Errorf("expected only the Media group, got %v", result.Groups)
+	}
+
+	wal := slices.Clone(data)
+	wal[18], wal[19] = 2, 2
+	result, err = Convert("", wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(result.Unmapped, func(unmapped UnmappedItem) bool { return unmapped.Item == "app.sqlite" }) {
+		t.Errorf("expected a warning about the write-ahead log, got %v", result.Unmapped)
+	}
+}
+
+func TestConvertHeimdallDatabaseFormat(t *testing.T) {
+	data, err := os.ReadFile("testdata/heimdall/app.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Convert(FormatHomer, data); err == nil {
+		t.Error("expected an error for a database read as Homer")
+	}
+}
+
+func TestConvertCorruptHeimdallDatabase(t *testing.T) {
+	data, err := os.ReadFile("testdata/heimdall/app.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Convert("", data[:3000]); err == nil {
+		t.Error("expected an error for a truncated database")
+	}
+
+	// Damaged files must fail or convert, but never panic
+	for offset := 100; offset < len(data); offset += 31 {
+		damaged := slices.Clone(data)
+		damaged[offset] ^= 0xff
+		Convert("", damaged)
+	}
+}
+
+func TestConvertHeimdallExport(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg"})
+
+	export := `[
+		{"id":1,"title":"Media","icon":"icons/folder.png","url":"media","description":null,"type":1,"class":null,"deleted_at":null,"appdescription":null},
+		{"id":2,"title":"Jellyfin","icon":"icons/jellyfin.png","url":"https://jellyfin.example.com","description":"{\"enabled\":true}","type":0,"class":"App\\SupportedApps\\Jellyfin\\Jellyfin","deleted_at":null,"appdescription":"Movies"},
+		{"id":3,"title":"Old wiki","icon":null,"url":"https://wiki.example.com","description":null,"type":0,"class":null,"deleted_at":"2024-02-01 09:00:00","appdescription":null}
+	]`
+
+	result, err := Convert("", []byte(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != FormatHeimdall || len(result.Apps) != 1 {
+		t.Fatalf("expected one Heimdall application, got %+v", result)
+	}
+	if app := result.Apps[0]; app.Name != "Jellyfin" || app.Icon != "jellyfin" || app.Description != "Movies" {
+		t.Errorf("unexpected application %+v", app)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import (
+	"fmt"
+
+	"go.yaml.in/yaml/v3"
+)
+
+type homepageService struct {
+	Href        string `yaml:"href"`
+	Icon        string `yaml:"icon"`
+	Description string `yaml:"description"`
+	Target      string `yaml:"target"`
+}
+
+// convertHomepage reads a Homepage services.yaml, a list of groups that each
+// hold a list of services or nested groups.
+func convertHomepage(root *yaml.Node, result *Result) error {
+	if root.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: expected a list of groups", root.Line)
+	}
+
+	return homepageGroups(root, result)
+}
+
+func homepageGroups(list *yaml.Node, result *Result) error {
+	for _, entry := range list.Content {
+		if entry.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: expected a group", entry.Line)
+		}
+
+		for i := 0; i+1 < len(entry.Content); i += 2 {
+			group, services := entry.Content[i].Value, entry.Content[i+1]
+			if services.Kind != yaml.SequenceNode {
+				return fmt.Errorf("line %d: expected a list of services in %s", services.Line, group)
+			}
+
+			if err := homepageServices(group, services, result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func homepageServices(group string, services *yaml.Node, result *Result) error {
+	for _, entry := range services.Content {
+		if entry.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: expected a service", entry.Line)
+		}
+
+		for i := 0; i+1 < len(entry.Content); i += 2 {
+			name, value := entry.Content[i].Value, entry.Content[i+1]
+
+			// Groups can be nested, HomeDash groups can't
+			if value.Kind == yaml.SequenceNode {
+				if err := homepageServices(name, value, result); err != nil {
+					return err
+				}
+				continue
+			}
+
+			service := homepageService{}
+			if err := value.Decode(&service); err != nil {
+				return err
+			}
+
+			result.add(group, item{
+				Name:    name,
+				Url:     service.Href,
+				Icon:    service.Icon,
+				Comment: service.Description,
+				Target:  mapTarget(service.Target),
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import "go.yaml.in/yaml/v3"
+
+type homerConfig struct {
+	Services []struct {
+		Name  string `yaml:"name"`
+		Items []struct {
+			Name     string `yaml:"name"`
+			Url      string `yaml:"url"`
+			Logo     string `yaml:"logo"`
+			Icon     string `yaml:"icon"`
+			Subtitle string `yaml:"subtitle"`
+			Tag      string `yaml:"tag"`
+			Target   string `yaml:"target"`
+		} `yaml:"items"`
+	} `yaml:"services"`
+}
+
+// convertHomer reads a Homer config.yml. Every service group becomes a group.
+func convertHomer(root *yaml.Node, result *Result) error {
+	config := homerConfig{}
+	if err := root.Decode(&config); err != nil {
+		return err
+	}
+
+	for _, group := range config.Services {
+		for _, link := range group.Items {
+			// Homer has logos and Font Awesome icons, only logos can be mapped
+			icon := link.Logo
+			if icon == "" {
+				icon = link.Icon
+			}
+
+			tags := []string{}
+			if link.Tag != "" {
+				tags = append(tags, link.Tag)
+			}
+
+			result.add(group.Name, item{
+				Name:    link.Name,
+				Url:     link.Url,
+				Icon:    icon,
+				Comment: link.Subtitle,
+				Target:  mapTarget(link.Target),
+				Tags:    tags,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+// Package importer converts the configuration of other dashboards into
+// HomeDash static applications and groups.
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+const (
+	FormatHomer    = "homer"
+	FormatHomepage = "homepage"
+	FormatDashy    = "dashy"
+	FormatHeimdall = "heimdall"
+)
+
+var ErrUnknownFormat = errors.New("unknown format, expected homer, homepage, dashy or heimdall")
+
+// Result holds the converted applications and groups, and the items that
+// could not be converted completely.
+type Result struct {
+	Format   string            `json:"format"`
+	Apps     []m.ContainerInfo `json:"apps"`
+	Groups   []m.Group         `json:"groups"`
+	Unmapped []UnmappedItem    `json:"unmapped"`
+}
+
+// UnmappedItem is an item that was skipped, or imported without some of its
+// settings.
+type UnmappedItem struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+// dropInApp holds the fields of an application that can be imported, named
+// like in config.yml.
+type dropInApp struct {
+	Name        string   `yaml:"name"`
+	Url         string   `yaml:"url"`
+	Icon        string   `yaml:"icon,omitempty"`
+	Comment     string   `yaml:"comment,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Target      string   `yaml:"target,omitempty"`
+	Group       string   `yaml:"group,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+}
+
+// DropIn returns the applications and groups as a file for the apps
+// directory.
+func (r Result) DropIn() ([]byte, error) {
+	file := struct {
+		Apps   []dropInApp `yaml:"apps"`
+		Groups []struct {
+			Name string `yaml:"name"`
+		} `yaml:"groups,omitempty"`
+	}{Apps: []dropInApp{}}
+
+	for _, app := range r.Apps {
+		file.Apps = append(file.Apps, dropInApp{
+			Name:        app.Name,
+			Url:         app.Url,
+			Icon:        app.Icon,
+			Comment:     app.Comment,
+			Description: app.Description,
+			Target:      app.Target,
+			Group:       app.Group,
+			Tags:        app.Tags,
+		})
+	}
+	for _, group := range r.Groups {
+		file.Groups = append(file.Groups, struct {
+			Name string `yaml:"name"`
+		}{group.Name})
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// item is a link on another dashboard.
+type item struct {
+	Name        string
+	Url         string
+	Icon        string
+	Comment     string
+	Description string
+	Target      string
+	Tags        []string
+}
+
+// Convert reads the configuration in the format. Without a format, it is
+// detected from the contents. SQLite databases are read as Heimdall's
+// app.sqlite.
+func Convert(format string, data []byte) (Result, error) {
+	if bytes.HasPrefix(data, sqliteHeader) {
+		if format != "" && format != FormatHeimdall {
+			return Result{}, fmt.Errorf("invalid %s configuration: the file is an SQLite database", format)
+		}
+
+		result := &Result{Format: FormatHeimdall, Apps: []m.ContainerInfo{}, Groups: []m.Group{}, Unmapped: []UnmappedItem{}}
+		if err := convertHeimdallDatabase(data, result); err != nil {
+			return Result{}, fmt.Errorf("invalid heimdall database: %w", err)
+		}
+
+		return *result, nil
+	}
+
+	// JSON is valid YAML, so one parser reads every format
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return Result{}, err
+	}
+	if len(document.Content) == 0 {
+		return Result{}, errors.New("the file is empty")
+	}
+	root := document.Content[0]
+
+	if format == "" {
+		format = detect(root)
+	}
+
+	result := &Result{Format: format, Apps: []m.ContainerInfo{}, Groups: []m.Group{}, Unmapped: []UnmappedItem{}}
+
+	var err error
+	switch format {
+	case FormatHomer:
+		err = convertHomer(root, result)
+	case FormatHomepage:
+		err = convertHomepage(root, result)
+	case FormatDashy:
+		err = convertDashy(root, result)
+	case FormatHeimdall:
+		err = convertHeimdall(root, result)
+	default:
+		return Result{}, ErrUnknownFormat
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid %s configuration: %w", format, err)
+	}
+
+	return *result, nil
+}
+
+// detect guesses the format from the structure of the document.
+func detect(root *yaml.Node) string {
+	switch root.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			switch root.Content[i].Value {
+			case "services":
+				return FormatHomer
+			case "sections":
+				return FormatDashy
+			}
+		}
+	case yaml.SequenceNode:
+		if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+			return ""
+		}
+		first := root.Content[0]
+		for i := 0; i+1 < len(first.Content); i += 2 {
+			if first.Content[i].Value == "title" {
+				return FormatHeimdall
+			}
+		}
+		if len(first.Content) == 2 && first.Content[1].Kind == yaml.SequenceNode {
+			return FormatHomepage
+		}
+	}
+
+	return ""
+}
+
+// add converts an item into an application of the group. Items without a
+// name or URL are skipped, items without a known icon are imported without.
+func (r *Result) add(group string, link item) {
+	name := strings.TrimSpace(link.Name)
+	if name == "" {
+		name = link.Url
+	}
+	if name == "" {
+		r.Unmapped = append(r.Unmapped, UnmappedItem{Item: group, Reason: "skipped an item without name and url"})
+		return
+	}
+	if strings.TrimSpace(link.Url) == "" {
+		r.Unmapped = append(r.Unmapped, UnmappedItem{Item: name, Reason: "skipped, it has no url"})
+		return
+	}
+
+	icon := mapIcon(link.Icon, name)
+	if icon == "" {
+		reason := "imported without icon, no icon found for " + name
+		if link.Icon != "" {
+			reason = "imported without icon, no icon found for " + link.Icon
+		}
+		r.Unmapped = append(r.Unmapped, UnmappedItem{Item: name, Reason: reason})
+	}
+
+	r.Apps = append(r.Apps, m.ContainerInfo{
+		Name:        name,
+		Url:         strings.TrimSpace(link.Url),
+		Icon:        icon,
+		Comment:     link.Comment,
+		Description: link.Description,
+		Target:      link.Target,
+		Group:       group,
+		Tags:        link.Tags,
+	})
+
+	if group != "" && !slices.ContainsFunc(r.Groups, func(existing m.Group) bool {
+		return existing.Name == group
+	}) {
+		r.Groups = append(r.Groups, m.Group{Name: group})
+	}
+}
+
+// iconPrefixes are used by other dashboards to pick an icon set, like
+// hl-jellyfin for the homelab icons in Dashy.
+var iconPrefixes = []string{"hl-", "sh-", "si-", "mdi-", "di-"}
+
+// mapIcon looks up an icon of another dashboard, like jellyfin.png, hl-jellyfin
+// or a URL, in the icon index. It falls back to the names.
+func mapIcon(icon string, names ...string) string {
+	candidates := []string{}
+
+	if icon = strings.TrimSpace(icon); icon != "" {
+		icon, _, _ = strings.Cut(icon, "?")
+		icon = path.Base(icon)
+		icon = strings.TrimSuffix(icon, path.Ext(icon))
+		for _, prefix := range iconPrefixes {
+			icon = strings.TrimPrefix(icon, prefix)
+		}
+		candidates = append(candidates, icon)
+	}
+
+	return c.GuessIcon(append(candidates, names...)...)
+}
+
+// mapTarget converts the ways dashboards open links into a HomeDash target.
+func mapTarget(target string) string {
+	switch strings.ToLower(target) {
+	case "_blank", "newtab":
+		return m.TargetNewTab
+	case "_self", "sametab", "_top", "_parent":
+		return m.TargetSameTab
+	}
+
+	return ""
+}
@@ -0,0 +1,478 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sqliteHeader starts every SQLite database file.
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+var errCorrupt = errors.New("the database is corrupt or truncated")
+
+// B-tree page types, see https://www.sqlite.org/fileformat.html
+const (
+	pageInteriorTable = 0x05
+	pageLeafTable     = 0x0d
+)
+
+// sqliteDatabase reads tables from the bytes of an SQLite database file. It
+// understands just enough of the file format to list the rows of a table:
+// there is no SQL, no indexes and no WITHOUT ROWID tables. Changes that are
+// still in a write-ahead log next to the file are not seen.
+type sqliteDatabase struct {
+	data     []byte
+	pageSize int
+	usable   int
+
+	// overflowPages counts the overflow pages read for a table. Each page
+	// belongs to one row, so reading more pages than the file has means the
+	// rows share them, which only happens in damaged or crafted files.
+	overflowPages int
+}
+
+func openSQLite(data []byte) (*sqliteDatabase, error) {
+	if len(data) < 100 || !bytes.HasPrefix(data, sqliteHeader) {
+		return nil, errors.New("not an SQLite database")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, errCorrupt
+	}
+
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding > 1 {
+		return nil, errors.New("only UTF-8 databases are supported")
+	}
+
+	return &sqliteDatabase{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+	}, nil
+}
+
+// walMode reports whether the database uses a write-ahead log, which can hold
+// changes that are not in the file yet.
+func (db *sqliteDatabase) walMode() bool {
+	return db.data[18] == 2
+}
+
+// table returns the rows of a table, with the values by column name. Values
+// are int64, float64, string, []byte or nil.
+func (db *sqliteDatabase) table(name string) ([]map[string]any, error) {
+	// The schema is a table itself, with the columns type, name, tbl_name,
+	// rootpage and sql
+	schema, err := db.rows(1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, object := range schema {
+		if len(object.values) < 5 || object.values[0] != "table" || !strings.EqualFold(asString(object.values[1]), name) {
+			continue
+		}
+
+		rootPage, isInt := object.values[3].(int64)
+		if !isInt {
+			return nil, errCorrupt
+		}
+		sql := asString(object.values[4])
+		if strings.Contains(strings.ToUpper(sql), "WITHOUT ROWID") {
+			return nil, fmt.Errorf("table %s is a WITHOUT ROWID table, which is not supported", name)
+		}
+		columns := tableColumns(sql)
+
+		records, err := db.rows(int(rootPage))
+		if err != nil {
+			return nil, err
+		}
+
+		rows := []map[string]any{}
+		for _, record := range records {
+			row := map[string]any{}
+			for i, column := range columns {
+				// Columns added later are missing from older rows, and an
+				// INTEGER PRIMARY KEY is stored as the rowid
+				var value any
+				if i < len(record.values) {
+					value = record.values[i]
+				}
+				if value == nil && column.rowid {
+					value = record.rowid
+				}
+				if integer, isInt := value.(int64); isInt && column.real {
+					value = float64(integer)
+				}
+				row[column.name] = value
+			}
+			rows = append(rows, row)
+		}
+
+		return rows, nil
+	}
+
+	return nil, fmt.Errorf("table %s not found", name)
+}
+
+type sqliteRecord struct {
+	rowid  int64
+	values []any
+}
+
+// rows walks the table b-tree starting at the root page and decodes the
+// records in its leaves, in rowid order.
+func (db *sqliteDatabase) rows(root int) ([]sqliteRecord, error) {
+	records := []sqliteRecord{}
+	visited := map[int]bool{}
+	db.overflowPages = 0
+
+	var walk func(page int) error
+	walk = func(page int) error {
+		if visited[page] {
+			return errCorrupt
+		}
+		visited[page] = true
+
+		data, headerStart, err := db.page(page)
+		if err != nil {
+			return err
+		}
+		if len(data) < headerStart+8 {
+			return errCorrupt
+		}
+
+		pageType := data[headerStart]
+		cells := int(binary.BigEndian.Uint16(data[headerStart+3:]))
+		pointers := headerStart + 8
+		if pageType == pageInteriorTable {
+			pointers = headerStart + 12
+		}
+		if len(data) < pointers+2*cells {
+			return errCorrupt
+		}
+
+		for i := range cells {
+			offset := int(binary.BigEndian.Uint16(data[pointers+2*i:]))
+			if offset >= len(data) {
+				return errCorrupt
+			}
+			cell := data[offset:]
+
+			switch pageType {
+			case pageInteriorTable:
+				if len(cell) < 4 {
+					return errCorrupt
+				}
+				if err := walk(int(binary.BigEndian.Uint32(cell))); err != nil {
+					return err
+				}
+			case pageLeafTable:
+				record, err := db.leafCell(cell)
+				if err != nil {
+					return err
+				}
+				records = append(records, record)
+			default:
+				return fmt.Errorf("unexpected page type %#x in a table", pageType)
+			}
+		}
+
+		if pageType == pageInteriorTable {
+			return walk(int(binary.BigEndian.Uint32(data[headerStart+8:])))
+		}
+
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// page returns a page and where its b-tree header starts, which is after the
+// database header on the first page.
+func (db *sqliteDatabase) page(number int) ([]byte, int, error) {
+	if number < 1 || number > len(db.data)/db.pageSize {
+		return nil, 0, errCorrupt
+	}
+	start := (number - 1) * db.pageSize
+
+	headerStart := 0
+	if number == 1 {
+		headerStart = 100
+	}
+
+	return db.data[start : start+db.usable], headerStart, nil
+}
+
+// leafCell decodes a cell of a table leaf page. Payloads that don't fit on the
+// page continue on a chain of overflow pages.
+func (db *sqliteDatabase) leafCell(cell []byte) (sqliteRecord, error) {
+	size, n := readVarint(cell)
+	if n == 0 {
+		return sqliteRecord{}, errCorrupt
+	}
+	cell = cell[n:]
+	rowid, n := readVarint(cell)
+	if n == 0 {
+		return sqliteRecord{}, errCorrupt
+	}
+	cell = cell[n:]
+
+	if size > uint64(len(db.data)) {
+		return sqliteRecord{}, errCorrupt
+	}
+	payloadSize := int(size)
+
+	maxLocal := db.usable - 35
+	local := payloadSize
+	if payloadSize > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (payloadSize-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if len(cell) < local {
+		return sqliteRecord{}, errCorrupt
+	}
+
+	payload := make([]byte, 0, payloadSize)
+	payload = append(payload, cell[:local]...)
+
+	if local < payloadSize {
+		if len(cell) < local+4 {
+			return sqliteRecord{}, errCorrupt
+		}
+		next := int(binary.BigEndian.Uint32(cell[local:]))
+		for len(payload) < payloadSize {
+			db.overflowPages++
+			if db.overflowPages > len(db.data)/db.pageSize {
+				return sqliteRecord{}, errCorrupt
+			}
+
+			data, _, err := db.page(next)
+			if err != nil {
+				return sqliteRecord{}, err
+			}
+			next = int(binary.BigEndian.Uint32(data))
+			payload = append(payload, data[4:min(len(data), 4+payloadSize-len(payload))]...)
+		}
+	}
+
+	values, err := decodeRecord(payload)
+	if err != nil {
+		return sqliteRecord{}, err
+	}
+
+	return sqliteRecord{rowid: int64(rowid), values: values}, nil
+}
+
+// decodeRecord decodes the values of a record: a header with the serial type
+// of each column, followed by the values.
+func decodeRecord(payload []byte) ([]any, error) {
+	headerSize, n := readVarint(payload)
+	if n == 0 || headerSize < uint64(n) || headerSize > uint64(len(payload)) {
+		return nil, errCorrupt
+	}
+	header := payload[n:headerSize]
+	body := payload[headerSize:]
+
+	values := []any{}
+	for len(header) > 0 {
+		serialType, n := readVarint(header)
+		if n == 0 {
+			return nil, errCorrupt
+		}
+		header = header[n:]
+
+		size := serialSize(serialType)
+		if size > uint64(len(body)) {
+			return nil, errCorrupt
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			values = append(values, nil)
+		case serialType >= 1 && serialType <= 6:
+			// Big-endian two's complement integers of 1 to 8 bytes
+			value := int64(int8(field[0]))
+			for _, b := range field[1:] {
+				value = value<<8 | int64(b)
+			}
+			values = append(values, value)
+		case serialType == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case serialType == 8 || serialType == 9:
+			values = append(values, int64(serialType-8))
+		case serialType >= 12 && serialType%2 == 0:
+			values = append(values, bytes.Clone(field))
+		case serialType >= 13:
+			values = append(values, string(field))
+		default:
+			return nil, errCorrupt
+		}
+	}
+
+	return values, nil
+}
+
+// serialSize returns the size of a value of the serial type.
+func serialSize(serialType uint64) uint64 {
+	switch {
+	case serialType <= 4:
+		return serialType
+	case serialType == 5:
+		return 6
+	case serialType == 6 || serialType == 7:
+		return 8
+	case serialType >= 12:
+		return (serialType - 12) / 2
+	}
+
+	return 0
+}
+
+// readVarint reads a variable-length integer of 1 to 9 bytes. It returns 0
+// bytes read when the buffer ends first.
+func readVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i := range min(len(data), 9) {
+		if i == 8 {
+			return value<<8 | uint64(data[i]), 9
+		}
+		value = value<<7 | uint64(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+type sqliteColumn struct {
+	name  string
+	rowid bool
+	real  bool
+}
+
+// tableColumns reads the column names from a CREATE TABLE statement. A column
+// declared as INTEGER PRIMARY KEY is an alias for the rowid. Columns with REAL
+// affinity store whole numbers as integers, which are read back as floats.
+func tableColumns(sql string) []sqliteColumn {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	columns := []sqliteColumn{}
+	for _, definition := range splitDefinitions(sql[start+1 : end]) {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+
+		declaration := strings.ToUpper(strings.Join(fields[1:], " "))
+		columns = append(columns, sqliteColumn{
+			name:  strings.Trim(fields[0], "\"`[]'"),
+			rowid: strings.HasPrefix(declaration, "INTEGER") && strings.Contains(declaration, "PRIMARY KEY"),
+			real:  realAffinity(fields[1:]),
+		})
+	}
+
+	return columns
+}
+
+// realAffinity applies the rules SQLite uses to find the affinity of a
+// column to the words after its name, up to the first constraint.
+func realAffinity(words []string) bool {
+	declaredType := []string{}
+	for _, word := range words {
+		switch strings.ToUpper(word) {
+		case "CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK", "DEFAULT", "COLLATE", "REFERENCES", "GENERATED", "AS":
+			return isReal(strings.ToUpper(strings.Join(declaredType, " ")))
+		}
+		declaredType = append(declaredType, word)
+	}
+
+	return isReal(strings.ToUpper(strings.Join(declaredType, " ")))
+}
+
+func isReal(declaredType string) bool {
+	for _, other := range []string{"INT", "CHAR", "CLOB", "TEXT", "BLOB"} {
+		if strings.Contains(declaredType, other) {
+			return false
+		}
+	}
+
+	return strings.Contains(declaredType, "REAL") || strings.Contains(declaredType, "FLOA") || strings.Contains(declaredType, "DOUB")
+}
+
+// splitDefinitions splits the column definitions on the commas that are not
+// inside parentheses or quotes.
+func splitDefinitions(definitions string) []string {
+	parts := []string{}
+	depth, quote, start := 0, rune(0), 0
+
+	for i, r := range definitions {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, definitions[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, definitions[start:])
+}
+
+// asString returns text and blob values as a string, and "" otherwise.
+func asString(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	}
+
+	return ""
+}
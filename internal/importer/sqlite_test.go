@@ -0,0 +1,225 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The tables.sqlite fixture has 512 byte pages, so the rows table is three
+// levels deep and the longer notes continue on overflow pages. It also has an
+// index and a WITHOUT ROWID table.
+func readFixture(t testing.TB, name string) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestReadTableInteriorPages(t *testing.T) {
+	db, err := openSQLite(readFixture(t, "sqlite/tables.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.table("rows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 600 {
+		t.Fatalf("expected 600 rows, got %d", len(rows))
+	}
+
+	for i, row := range rows {
+		n := int64(i + 1)
+		data := bytes.Repeat([]byte{byte(n % 256)}, int(n%5))
+		if row["id"] != n || row["title"] != fmt.Sprintf("row %04d ", n)+strings.Repeat("x", 50) ||
+			row["amount"] != float64(n)/4 || !bytes.Equal(row["data"].([]byte), data) || row["negative"] != -n*1000003 {
+			t.Fatalf("unexpected row %d: %v", n, row)
+		}
+	}
+}
+
+func TestReadTableOverflowPages(t *testing.T) {
+	db, err := openSQLite(readFixture(t, "sqlite/tables.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.table("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lengths := []int{}
+	for _, row := range rows {
+		body := asString(row["body"])
+		if !strings.HasPrefix(body, fmt.Sprintf("note %d n", len(body))) || strings.Trim(body[strings.Index(body, " n")+1:], "n") != "" {
+			t.Errorf("unexpected note %.40q", body)
+		}
+		lengths = append(lengths, len(body))
+	}
+	if !slices.Equal(lengths, []int{100, 600, 3000}) {
+		t.Errorf("expected notes of 100, 600 and 3000 bytes, got %v", lengths)
+	}
+}
+
+func TestReadTableErrors(t *testing.T) {
+	db, err := openSQLite(readFixture(t, "sqlite/tables.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.table("settings"); err == nil || !strings.Contains(err.Error(), "WITHOUT ROWID") {
+		t.Errorf("expected WITHOUT ROWID tables to be refused, got %v", err)
+	}
+	if _, err := db.table("rows_negative"); err == nil {
+		t.Error("expected an index not to be read as a table")
+	}
+	if _, err := db.table("missing"); err == nil {
+		t.Error("expected an error for a missing table")
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	valid := readFixture(t, "sqlite/tables.sqlite")
+
+	tests := []struct {
+		name   string
+		change func([]byte)
+	}{
+		{"page size not a power of two", func(data []byte) { data[16], data[17] = 0x03, 0x00 }},
+		{"page size too small", func(data []byte) { data[16], data[17] = 0x01, 0x00 }},
+		{"utf-16", func(data []byte) { data[59] = 2 }},
+		{"not sqlite", func(data []byte) { data[0] = 'X' }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := slices.Clone(valid[:1024])
+			test.change(data)
+			if _, err := openSQLite(data); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := openSQLite(valid[:99]); err == nil {
+		t.Error("expected an error for a file shorter than the header")
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		value uint64
+		n     int
+	}{
+		{[]byte{0x00}, 0, 1},
+		{[]byte{0x7f}, 127, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0x82, 0x80, 0x01}, 0x8001, 3},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 0xffffffffffffffff, 9},
+		{[]byte{0x81}, 0, 0},
+		{[]byte{}, 0, 0},
+	}
+
+	for _, test := range tests {
+		if value, n := readVarint(test.data); value != test.value || n != test.n {
+			t.Errorf("%x: expected %d in %d bytes, got %d in %d", test.data, test.value, test.n, value, n)
+		}
+	}
+}
+
+func TestDecodeRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		values  []any
+		err     bool
+	}{
+		{"null, constants and text", []byte{5, 0, 8, 9, 17, 'a', 'b'}, []any{nil, int64(0), int64(1), "ab"}, false},
+		{"negative integers", []byte{3, 1, 2, 0xff, 0xff, 0x7f}, []any{int64(-1), int64(-129)}, false},
+		{"blob", []byte{2, 16, 1, 2}, []any{[]byte{1, 2}}, false},
+		{"reserved serial type", []byte{2, 10}, nil, true},
+		{"value past the end", []byte{2, 6, 1, 2}, nil, true},
+		{"header past the end", []byte{9, 1}, nil, true},
+		{"header shorter than its size", []byte{0}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := decodeRecord(test.payload)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(values) != fmt.Sprint(test.values) {
+				t.Errorf("expected %v, got %v", test.values, values)
+			}
+		})
+	}
+}
+
+func TestTableColumns(t *testing.T) {
+	columns := tableColumns(`CREATE TABLE "item_tag" ("item_id" integer not null, "tag_id" integer not null, "note" varchar(255, 2) default 'a, b', foreign key("item_id") references "items"("id") on delete cascade, primary key ("item_id", "tag_id"))`)
+
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, column.name)
+	}
+	if !slices.Equal(names, []string{"item_id", "tag_id", "note"}) {
+		t.Errorf("unexpected columns %v", names)
+	}
+
+	if columns := tableColumns("CREATE TABLE items (id INTEGER PRIMARY KEY, [title] TEXT)"); len(columns) != 2 || !columns[0].rowid || columns[1].name != "title" {
+		t.Errorf("unexpected columns %+v", columns)
+	}
+
+	affinities := []bool{}
+	for _, column := range tableColumns("CREATE TABLE t (a REAL, b double precision not null, c FLOATING POINT, d integer, e text default 'real', f)") {
+		affinities = append(affinities, column.real)
+	}
+	if !slices.Equal(affinities, []bool{true, true, false, false, false, false}) {
+		t.Errorf("unexpected REAL affinities %v", affinities)
+	}
+}
+
+// FuzzReadTable checks that damaged or hostile files fail to read, instead of
+// panicking or hanging.
+func FuzzReadTable(f *testing.F) {
+	data := readFixture(f, "sqlite/tables.sqlite")
+	f.Add(data)
+	f.Add(data[:4096])
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		db, err := openSQLite(data)
+		if err != nil {
+			return
+		}
+		for _, table := range []string{"rows", "notes", "items", "item_tag"} {
+			db.table(table)
+		}
+	})
+}
@@ -0,0 +1,313 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package mdns
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries limits the memory used by a busy network.
+const maxCacheEntries = 2000
+
+// Instance is a service instance found on the network.
+type Instance struct {
+	// Name is the unescaped instance name, like "Living room printer"
+	Name    string
+	Service string
+	// Host is the host name without the trailing dot, like "printer.local"
+	Host string
+	Port int
+	IPs  []net.IP
+	Text map[string]string
+}
+
+type cacheEntry struct {
+	record   Record
+	received time.Time
+	expires  time.Time
+}
+
+// Browser looks for instances of service types like _http._tcp and keeps
+// their records until they expire.
+type Browser struct {
+	services      []string
+	interfaceName string
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+func NewBrowser(services []string, interfaceName string) *Browser {
+	return &Browser{
+		services:      services,
+		interfaceName: interfaceName,
+		cache:         map[string]cacheEntry{},
+	}
+}
+
+// Run browses until ctx is done. Queries are repeated every interval and
+// onChange is called whenever instances may have appeared or disappeared.
+func (b *Browser) Run(ctx context.Context, interval time.Duration, onChange func()) error {
+	conn, err := listen(b.interfaceName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	go func() {
+		// Ask again shortly after starting, in case the first query got lost
+		for _, delay := range []time.Duration{0, time.Second} {
+			if !sleep(ctx, delay) {
+				return
+			}
+			b.query(conn)
+		}
+
+		for sleep(ctx, interval) {
+			if b.expire() {
+				onChange()
+			}
+			b.query(conn)
+		}
+	}()
+
+	return readMessages(conn, func(message *Message, _ *net.UDPAddr) {
+		if message.Response && b.add(append(message.Answers, message.Additionals...)) {
+			onChange()
+		}
+	})
+}
+
+// query asks for the service types, and for the details of instances that
+// are missing them.
+func (b *Browser) query(conn *net.UDPConn) {
+	message := &Message{}
+	for _, service := range b.services {
+		message.Questions = append(message.Questions, Question{Name: ServiceName(service), Type: TypePTR})
+	}
+	message.Questions = append(message.Questions, b.missing()...)
+
+	// Failing to send is noticed by missing instances, the next query may work
+	_ = send(conn, message, groupAddress)
+}
+
+// missing returns questions for the instances without SRV record and hosts
+// without address.
+func (b *Browser) missing() []Question {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	questions := []Question{}
+	for _, entry := range b.cache {
+		switch entry.record.Type {
+		case TypePTR:
+			if !b.has(entry.record.Target, TypeSRV) {
+				questions = append(questions, Question{Name: entry.record.Target, Type: TypeANY})
+			}
+		case TypeSRV:
+			if !b.has(entry.record.Target, TypeA) {
+				questions = append(questions, Question{Name: entry.record.Target, Type: TypeA})
+			}
+		}
+	}
+
+	return questions
+}
+
+// has reports whether a record for the name and type is known. The caller
+// holds the lock.
+func (b *Browser) has(name string, recordType uint16) bool {
+	for _, entry := range b.cache {
+		if entry.record.Type == recordType && strings.EqualFold(entry.record.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// add caches the records about the browsed services. It reports whether
+// records were added or removed.
+func (b *Browser) add(records []Record) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	changed := false
+
+	for _, record := range records {
+		if !b.relevant(record) {
+			continue
+		}
+		key := cacheKey(record)
+
+		// Records with a TTL of 0 say goodbye
+		if record.TTL == 0 {
+			if _, exists := b.cache[key]; exists {
+				delete(b.cache, key)
+				changed = true
+			}
+			continue
+		}
+
+		// The cache-flush bit replaces older records with the same name and
+		// type, the ones received in the last second belong to this answer
+		if record.CacheFlush {
+			for otherKey, entry := range b.cache {
+				if otherKey != key && entry.record.Type == record.Type && strings.EqualFold(entry.record.Name, record.Name) && now.Sub(entry.received) > time.Second {
+					delete(b.cache, otherKey)
+					changed = true
+				}
+			}
+		}
+
+		if _, exists := b.cache[key]; !exists {
+			if len(b.cache) >= maxCacheEntries {
+				continue
+			}
+			changed = true
+		}
+		b.cache[key] = cacheEntry{record: record, received: now, expires: now.Add(time.Duration(record.TTL) * time.Second)}
+	}
+
+	return changed
+}
+
+// relevant reports whether a record describes one of the browsed services.
+// Addresses are kept as they can belong to one of the instances.
+func (b *Browser) relevant(record Record) bool {
+	switch record.Type {
+	case TypePTR:
+		return slices.ContainsFunc(b.services, func(service string) bool {
+			return strings.EqualFold(record.Name, ServiceName(service))
+		})
+	case TypeSRV, TypeTXT:
+		return slices.ContainsFunc(b.services, func(service string) bool {
+			return strings.HasSuffix(strings.ToLower(record.Name), "."+strings.ToLower(ServiceName(service)))
+		})
+	case TypeA, TypeAAAA:
+		return true
+	}
+
+	return false
+}
+
+// expire removes the records whose TTL has passed and reports whether there
+// were any.
+func (b *Browser) expire() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	expired := false
+	for key, entry := range b.cache {
+		if now.After(entry.expires) {
+			delete(b.cache, key)
+			expired = true
+		}
+	}
+
+	return expired
+}
+
+// Instances returns the instances of the browsed services that have a host
+// and a port.
+func (b *Browser) Instances() []Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	live := []Record{}
+	for _, entry := range b.cache {
+		if now.Before(entry.expires) {
+			live = append(live, entry.record)
+		}
+	}
+	find := func(name string, recordType uint16) []Record {
+		found := []Record{}
+		for _, record := range live {
+			if record.Type == recordType && strings.EqualFold(record.Name, name) {
+				found = append(found, record)
+			}
+		}
+		return found
+	}
+
+	instances := []Instance{}
+	for _, service := range b.services {
+		for _, pointer := range find(ServiceName(service), TypePTR) {
+			srv := find(pointer.Target, TypeSRV)
+			if len(srv) == 0 {
+				continue
+			}
+
+			instance := Instance{
+				Name:    FirstLabel(pointer.Target),
+				Service: service,
+				Host:    strings.TrimSuffix(srv[0].Target, "."),
+				Port:    int(srv[0].Port),
+				Text:    map[string]string{},
+			}
+			for _, txt := range find(pointer.Target, TypeTXT) {
+				for _, text := range txt.Text {
+					key, value, _ := strings.Cut(text, "=")
+					instance.Text[strings.ToLower(key)] = value
+				}
+			}
+			for _, recordType := range []uint16{TypeA, TypeAAAA} {
+				for _, address := range find(srv[0].Target, recordType) {
+					instance.IPs = append(instance.IPs, address.IP)
+				}
+			}
+
+			instances = append(instances, instance)
+		}
+	}
+
+	slices.SortFunc(instances, func(a, b Instance) int {
+		return strings.Compare(a.Name+"."+a.Service, b.Name+"."+b.Service)
+	})
+
+	return instances
+}
+
+func cacheKey(record Record) string {
+	data := record.Target + "|" + strconv.Itoa(int(record.Port)) + "|" + strings.Join(record.Text, "\x00") + "|" + record.IP.String()
+
+	return strings.ToLower(record.Name) + "|" + strconv.Itoa(int(record.Type)) + "|" + data
+}
+
+// sleep waits for the duration and returns false when ctx is done first.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
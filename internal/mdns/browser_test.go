@@ -0,0 +1,167 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package mdns
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// announce returns the records a host sends to announce an instance.
+func announce(instance string, host string, port uint16, ip string) []Record {
+	name := InstanceName(instance, "_http._tcp")
+
+	return []Record{
+		{Name: ServiceName("_http._tcp"), Type: TypePTR, TTL: 4500, Target: name},
+		{Name: name, Type: TypeSRV, TTL: 120, CacheFlush: true, Port: port, Target: host + "."},
+		{Name: name, Type: TypeTXT, TTL: 4500, CacheFlush: true, Text: []string{"Path=/admin", "flag"}},
+		{Name: host + ".", Type: TypeA, TTL: 120, CacheFlush: true, IP: net.ParseIP(ip).To4()},
+	}
+}
+
+func TestBrowserInstances(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+
+	if !browser.add(announce("Printer", "printer.local", 631, "192.168.1.20")) {
+		t.Fatalf("expected the records to change the cache")
+	}
+	browser.add(announce("NAS.home", "nas.local", 5000, "192.168.1.10"))
+
+	// Instances without SRV record aren't complete yet
+	browser.add([]Record{{Name: ServiceName("_http._tcp"), Type: TypePTR, TTL: 4500, Target: InstanceName("Pending", "_http._tcp")}})
+
+	expected := []Instance{
+		{Name: "NAS.home", Service: "_http._tcp", Host: "nas.local", Port: 5000, IPs: []net.IP{net.ParseIP("192.168.1.10").To4()}, Text: map[string]string{"path": "/admin", "flag": ""}},
+		{Name: "Printer", Service: "_http._tcp", Host: "printer.local", Port: 631, IPs: []net.IP{net.ParseIP("192.168.1.20").To4()}, Text: map[string]string{"path": "/admin", "flag": ""}},
+	}
+	if instances := browser.Instances(); !reflect.DeepEqual(instances, expected) {
+		t.Errorf("expected %+v, got %+v", expected, instances)
+	}
+
+	questions := browser.missing()
+	if len(questions) != 1 || questions[0].Name != InstanceName("Pending", "_http._tcp") || questions[0].Type != TypeANY {
+		t.Errorf("expected a question for the pending instance, got %+v", questions)
+	}
+}
+
+func TestBrowserIgnoresOtherServices(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+
+	name := InstanceName("Speaker", "_airplay._tcp")
+	changed := browser.add([]Record{
+		{Name: ServiceName("_airplay._tcp"), Type: TypePTR, TTL: 4500, Target: name},
+		{Name: name, Type: TypeSRV, TTL: 120, Port: 7000, Target: "speaker.local."},
+		{Name: name, Type: TypeTXT, TTL: 4500, Text: []string{"model=speaker"}},
+	})
+
+	if changed || len(browser.cache) != 0 {
+		t.Errorf("expected the records to be ignored, got %+v", browser.cache)
+	}
+}
+
+func TestBrowserUnchangedRecords(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+	browser.add(announce("Printer", "printer.local", 631, "192.168.1.20"))
+
+	if browser.add(announce("Printer", "printer.local", 631, "192.168.1.20")) {
+		t.Errorf("expected repeated records not to change the cache")
+	}
+}
+
+func TestBrowserGoodbye(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+	browser.add(announce("Printer", "printer.local", 631, "192.168.1.20"))
+
+	goodbye := announce("Printer", "printer.local", 631, "192.168.1.20")[:1]
+	goodbye[0].TTL = 0
+	if !browser.add(goodbye) {
+		t.Errorf("expected the goodbye to change the cache")
+	}
+	if instances := browser.Instances(); len(instances) != 0 {
+		t.Errorf("expected no instances, got %+v", instances)
+	}
+	if browser.add(goodbye) {
+		t.Errorf("expected a repeated goodbye not to change the cache")
+	}
+}
+
+func TestBrowserCacheFlush(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+	browser.add(announce("Printer", "printer.local", 631, "192.168.1.20"))
+
+	// Records received in the same second belong to the same answer, so a
+	// second address is kept
+	browser.add([]Record{{Name: "printer.local.", Type: TypeA, TTL: 120, CacheFlush: true, IP: net.ParseIP("192.168.1.21").To4()}})
+	if instances := browser.Instances(); len(instances) != 1 || len(instances[0].IPs) != 2 {
+		t.Fatalf("expected both addresses, got %+v", instances)
+	}
+
+	// Later, a flushing record replaces the older ones
+	browser.mu.Lock()
+	for key, entry := range browser.cache {
+		entry.received = entry.received.Add(-2 * time.Second)
+		browser.cache[key] = entry
+	}
+	browser.mu.Unlock()
+
+	browser.add([]Record{{Name: "printer.local.", Type: TypeA, TTL: 120, CacheFlush: true, IP: net.ParseIP("192.168.1.22").To4()}})
+	instances := browser.Instances()
+	if len(instances) != 1 || len(instances[0].IPs) != 1 || !instances[0].IPs[0].Equal(net.ParseIP("192.168.1.22")) {
+		t.Errorf("expected only the new address, got %+v", instances)
+	}
+}
+
+func TestBrowserExpire(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+	browser.add(announce("Printer", "printer.local", 631, "192.168.1.20"))
+
+	if browser.expire() {
+		t.Errorf("expected nothing to expire yet")
+	}
+
+	browser.mu.Lock()
+	for key, entry := range browser.cache {
+		if entry.record.Type == TypeSRV {
+			entry.expires = time.Now().Add(-time.Second)
+			browser.cache[key] = entry
+		}
+	}
+	browser.mu.Unlock()
+
+	// Expired records are left out before they are removed
+	if instances := browser.Instances(); len(instances) != 0 {
+		t.Errorf("expected no instances, got %+v", instances)
+	}
+	if !browser.expire() {
+		t.Errorf("expected the SRV record to expire")
+	}
+	if len(browser.cache) != 3 {
+		t.Errorf("expected 3 records, got %d", len(browser.cache))
+	}
+}
+
+func TestBrowserCacheLimit(t *testing.T) {
+	browser := NewBrowser([]string{"_http._tcp"}, "")
+
+	records := []Record{}
+	for i := range maxCacheEntries + 10 {
+		records = append(records, Record{Name: "host" + strconv.Itoa(i) + ".local.", Type: TypeA, TTL: 120, IP: net.IPv4(10, 0, byte(i>>8), byte(i))})
+	}
+	browser.add(records)
+
+	if len(browser.cache) != maxCacheEntries {
+		t.Errorf("expected %d records, got %d", maxCacheEntries, len(browser.cache))
+	}
+}
@@ -0,0 +1,73 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// groupAddress is where mDNS queries and responses are sent.
+var groupAddress = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// listen joins the mDNS group on the named interface, or on the default
+// multicast interface without a name. Go disables multicast loopback on the
+// connection, so other programs on this host don't see what is sent on it.
+func listen(interfaceName string) (*net.UDPConn, error) {
+	var iface *net.Interface
+	if interfaceName != "" {
+		var err error
+		if iface, err = net.InterfaceByName(interfaceName); err != nil {
+			return nil, fmt.Errorf("mdns: %w", err)
+		}
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", iface, groupAddress)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: failed to join the multicast group: %w", err)
+	}
+
+	return conn, nil
+}
+
+func send(conn *net.UDPConn, message *Message, address *net.UDPAddr) error {
+	b, err := message.Pack()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.WriteToUDP(b, address)
+	return err
+}
+
+// readMessages passes every valid message to handle until the connection is
+// closed. Invalid messages are ignored, the network is shared with others.
+func readMessages(conn *net.UDPConn, handle func(*Message, *net.UDPAddr)) error {
+	buffer := make([]byte, 9000)
+
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		message, err := Unpack(buffer[:n])
+		if err != nil {
+			continue
+		}
+		handle(message, from)
+	}
+}
@@ -0,0 +1,410 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+// Package mdns implements the parts of multicast DNS (RFC 6762) and DNS-based
+// service discovery (RFC 6763) needed to browse for services on the local
+// network and to advertise HomeDash itself.
+package mdns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// DNS record types
+const (
+	TypeA    uint16 = 1
+	TypePTR  uint16 = 12
+	TypeTXT  uint16 = 16
+	TypeAAAA uint16 = 28
+	TypeSRV  uint16 = 33
+	TypeANY  uint16 = 255
+)
+
+const (
+	classIN uint16 = 1
+
+	// The top bit of the class is the cache-flush bit in records and the
+	// unicast-response bit in questions.
+	classTopBit uint16 = 1 << 15
+
+	flagResponse      uint16 = 1 << 15
+	flagAuthoritative uint16 = 1 << 10
+
+	headerLength = 12
+
+	// maxPointers limits how many compression pointers are followed in a
+	// single name, to stop loops.
+	maxPointers = 16
+)
+
+var errTruncated = errors.New("mdns: message is truncated")
+
+type Question struct {
+	Name string
+	Type uint16
+
+	// Unicast asks for the response to be sent to the sender only.
+	Unicast bool
+}
+
+// Record is a resource record. Which of the data fields is used depends on
+// the type: Target for PTR and SRV, Port for SRV, Text for TXT and IP for A
+// and AAAA records.
+type Record struct {
+	Name       string
+	Type       uint16
+	TTL        uint32
+	CacheFlush bool
+
+	Target string
+	Port   uint16
+	Text   []string
+	IP     net.IP
+}
+
+// Message is a DNS message. Names are fully qualified with a trailing dot.
+// Dots and backslashes inside a label are escaped with a backslash.
+type Message struct {
+	ID          uint16
+	Response    bool
+	Questions   []Question
+	Answers     []Record
+	Additionals []Record
+}
+
+// Pack encodes the message. Names are not compressed, which is allowed and
+// keeps the encoder simple.
+func (m *Message) Pack() ([]byte, error) {
+	var flags uint16
+	if m.Response {
+		flags = flagResponse | flagAuthoritative
+	}
+
+	b := make([]byte, headerLength, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	binary.BigEndian.PutUint16(b[2:], flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Answers)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(m.Additionals)))
+
+	var err error
+	for _, question := range m.Questions {
+		if b, err = appendName(b, question.Name); err != nil {
+			return nil, err
+		}
+		class := classIN
+		if question.Unicast {
+			class |= classTopBit
+		}
+		b = binary.BigEndian.AppendUint16(b, question.Type)
+		b = binary.BigEndian.AppendUint16(b, class)
+	}
+
+	for _, record := range append(m.Answers, m.Additionals...) {
+		if b, err = appendRecord(b, record); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func appendRecord(b []byte, record Record) ([]byte, error) {
+	b, err := appendName(b, record.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	class := classIN
+	if record.CacheFlush {
+		class |= classTopBit
+	}
+	b = binary.BigEndian.AppendUint16(b, record.Type)
+	b = binary.BigEndian.AppendUint16(b, class)
+	b = binary.BigEndian.AppendUint32(b, record.TTL)
+
+	// The length of the data is filled in afterwards
+	lengthAt := len(b)
+	b = append(b, 0, 0)
+
+	switch record.Type {
+	case TypePTR:
+		b, err = appendName(b, record.Target)
+	case TypeSRV:
+		b = binary.BigEndian.AppendUint16(b, 0) // priority
+		b = binary.BigEndian.AppendUint16(b, 0) // weight
+		b = binary.BigEndian.AppendUint16(b, record.Port)
+		b, err = appendName(b, record.Target)
+	case TypeTXT:
+		if len(record.Text) == 0 {
+			// A TXT record must contain at least one, possibly empty, string
+			b = append(b, 0)
+		}
+		for _, text := range record.Text {
+			if len(text) > 255 {
+				return nil, fmt.Errorf("mdns: text %q is longer than 255 bytes", text)
+			}
+			b = append(b, byte(len(text)))
+			b = append(b, text...)
+		}
+	case TypeA:
+		ip := record.IP.To4()
+		if ip == nil {
+			return nil, fmt.Errorf("mdns: %s is not an IPv4 address", record.IP)
+		}
+		b = append(b, ip...)
+	case TypeAAAA:
+		ip := record.IP.To16()
+		if ip == nil {
+			return nil, fmt.Errorf("mdns: %s is not an IPv6 address", record.IP)
+		}
+		b = append(b, ip...)
+	default:
+		return nil, fmt.Errorf("mdns: can't encode records of type %d", record.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	binary.BigEndian.PutUint16(b[lengthAt:], uint16(len(b)-lengthAt-2))
+
+	return b, nil
+}
+
+func appendName(b []byte, name string) ([]byte, error) {
+	for _, label := range splitName(name) {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("mdns: invalid label %q in %q", label, name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	return append(b, 0), nil
+}
+
+// splitName splits a name into its unescaped labels.
+func splitName(name string) []string {
+	labels := []string{}
+	label := []byte{}
+
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\' && i+1 < len(name):
+			i++
+			label = append(label, name[i])
+		case name[i] == '.':
+			labels = append(labels, string(label))
+			label = label[:0]
+		default:
+			label = append(label, name[i])
+		}
+	}
+	if len(label) > 0 {
+		labels = append(labels, string(label))
+	}
+
+	return labels
+}
+
+// ServiceName returns the name of a service type like _http._tcp.
+func ServiceName(service string) string {
+	return service + ".local."
+}
+
+// InstanceName returns the name of an instance of a service type.
+func InstanceName(instance string, service string) string {
+	return escapeLabel(instance) + "." + ServiceName(service)
+}
+
+// joinLabels builds a name from unescaped labels.
+func joinLabels(labels []string) string {
+	escaped := []string{}
+	for _, label := range labels {
+		escaped = append(escaped, escapeLabel(label))
+	}
+
+	return strings.Join(escaped, ".") + "."
+}
+
+// FirstLabel returns the unescaped first label of a name, like the instance
+// name of a service instance.
+func FirstLabel(name string) string {
+	labels := splitName(name)
+	if len(labels) == 0 {
+		return ""
+	}
+
+	return labels[0]
+}
+
+func escapeLabel(label string) string {
+	return strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(label)
+}
+
+// Unpack decodes a message. Authority records are skipped, unknown record
+// types are kept without data.
+func Unpack(b []byte) (*Message, error) {
+	if len(b) < headerLength {
+		return nil, errTruncated
+	}
+
+	m := &Message{
+		ID:       binary.BigEndian.Uint16(b[0:]),
+		Response: binary.BigEndian.Uint16(b[2:])&flagResponse != 0,
+	}
+	questions := int(binary.BigEndian.Uint16(b[4:]))
+	answers := int(binary.BigEndian.Uint16(b[6:]))
+	authorities := int(binary.BigEndian.Uint16(b[8:]))
+	additionals := int(binary.BigEndian.Uint16(b[10:]))
+
+	offset := headerLength
+	for range questions {
+		name, next, err := readName(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(b) {
+			return nil, errTruncated
+		}
+		class := binary.BigEndian.Uint16(b[next+2:])
+		m.Questions = append(m.Questions, Question{
+			Name:    name,
+			Type:    binary.BigEndian.Uint16(b[next:]),
+			Unicast: class&classTopBit != 0,
+		})
+		offset = next + 4
+	}
+
+	for i := range answers + authorities + additionals {
+		record, next, err := readRecord(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next
+
+		switch {
+		case i < answers:
+			m.Answers = append(m.Answers, record)
+		case i >= answers+authorities:
+			m.Additionals = append(m.Additionals, record)
+		}
+	}
+
+	return m, nil
+}
+
+func readRecord(b []byte, offset int) (Record, int, error) {
+	name, offset, err := readName(b, offset)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	if offset+10 > len(b) {
+		return Record{}, 0, errTruncated
+	}
+
+	record := Record{
+		Name:       name,
+		Type:       binary.BigEndian.Uint16(b[offset:]),
+		CacheFlush: binary.BigEndian.Uint16(b[offset+2:])&classTopBit != 0,
+		TTL:        binary.BigEndian.Uint32(b[offset+4:]),
+	}
+	length := int(binary.BigEndian.Uint16(b[offset+8:]))
+	start := offset + 10
+	end := start + length
+	if end > len(b) {
+		return Record{}, 0, errTruncated
+	}
+	data := b[start:end]
+
+	switch record.Type {
+	case TypePTR:
+		record.Target, _, err = readName(b, start)
+	case TypeSRV:
+		if length < 7 {
+			return Record{}, 0, errTruncated
+		}
+		record.Port = binary.BigEndian.Uint16(data[4:])
+		record.Target, _, err = readName(b, start+6)
+	case TypeTXT:
+		for i := 0; i < len(data); {
+			size := int(data[i])
+			if i+1+size > len(data) {
+				return Record{}, 0, errTruncated
+			}
+			if size > 0 {
+				record.Text = append(record.Text, string(data[i+1:i+1+size]))
+			}
+			i += 1 + size
+		}
+	case TypeA:
+		if length != net.IPv4len {
+			return Record{}, 0, fmt.Errorf("mdns: invalid A record for %s", name)
+		}
+		record.IP = net.IP(slices.Clone(data))
+	case TypeAAAA:
+		if length != net.IPv6len {
+			return Record{}, 0, fmt.Errorf("mdns: invalid AAAA record for %s", name)
+		}
+		record.IP = net.IP(slices.Clone(data))
+	}
+	if err != nil {
+		return Record{}, 0, err
+	}
+
+	return record, end, nil
+}
+
+// readName reads a possibly compressed name and returns it with the offset
+// after it.
+func readName(b []byte, offset int) (string, int, error) {
+	labels := []string{}
+	next := -1
+
+	for pointers := 0; ; {
+		if offset >= len(b) {
+			return "", 0, errTruncated
+		}
+		length := int(b[offset])
+
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return joinLabels(labels), next, nil
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(b) {
+				return "", 0, errTruncated
+			}
+			if pointers++; pointers > maxPointers {
+				return "", 0, errors.New("mdns: too many compression pointers")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(b[offset:]) & 0x3FFF)
+		case length&0xC0 != 0:
+			return "", 0, fmt.Errorf("mdns: invalid label length %#x", length)
+		default:
+			if offset+1+length > len(b) {
+				return "", 0, errTruncated
+			}
+			labels = append(labels, string(b[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
@@ -0,0 +1,265 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package mdns
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// header returns a message header with the given section counts.
+func header(questions, answers, authorities, additionals byte) []byte {
+	return []byte{0, 0, 0x84, 0, 0, questions, 0, answers, 0, authorities, 0, additionals}
+}
+
+func TestPackUnpack(t *testing.T) {
+	message := &Message{
+		ID:       7,
+		Response: true,
+		Questions: []Question{
+			{Name: "_http._tcp.local.", Type: TypePTR, Unicast: true},
+		},
+		Answers: []Record{
+			{Name: "_http._tcp.local.", Type: TypePTR, TTL: 4500, Target: `Living\.room._http._tcp.local.`},
+			{Name: `Living\.room._http._tcp.local.`, Type: TypeSRV, TTL: 120, CacheFlush: true, Port: 8080, Target: "nas.local."},
+			{Name: `Living\.room._http._tcp.local.`, Type: TypeTXT, TTL: 4500, Text: []string{"path=/admin", "homedash.icon=nas"}},
+		},
+		Additionals: []Record{
+			{Name: "nas.local.", Type: TypeA, TTL: 120, IP: net.IPv4(192, 168, 1, 10).To4()},
+			{Name: "nas.local.", Type: TypeAAAA, TTL: 120, IP: net.ParseIP("fd00::10")},
+		},
+	}
+
+	packed, err := message.Pack()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unpacked, err := Unpack(packed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(unpacked, message) {
+		t.Errorf("expected %+v, got %+v", message, unpacked)
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+	}{
+		{name: "empty label", record: Record{Name: "a..local.", Type: TypePTR, Target: "b.local."}},
+		{name: "long label", record: Record{Name: string(bytes.Repeat([]byte("a"), 64)) + ".local.", Type: TypeA, IP: net.IPv4(127, 0, 0, 1)}},
+		{name: "long text", record: Record{Name: "a.local.", Type: TypeTXT, Text: []string{string(bytes.Repeat([]byte("a"), 256))}}},
+		{name: "IPv6 in A record", record: Record{Name: "a.local.", Type: TypeA, IP: net.ParseIP("fd00::1")}},
+		{name: "unknown type", record: Record{Name: "a.local.", Type: 99}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message := &Message{Response: true, Answers: []Record{test.record}}
+			if _, err := message.Pack(); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestPackEmptyText(t *testing.T) {
+	message := &Message{Response: true, Answers: []Record{{Name: "a._http._tcp.local.", Type: TypeTXT, TTL: 10}}}
+	packed, err := message.Pack()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The record holds a single empty string, which unpacks to no text
+	if !bytes.HasSuffix(packed, []byte{0, 1, 0}) {
+		t.Errorf("expected a single empty string, got %v", packed)
+	}
+	unpacked, err := Unpack(packed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unpacked.Answers[0].Text) != 0 {
+		t.Errorf("expected no text, got %q", unpacked.Answers[0].Text)
+	}
+}
+
+func TestReadName(t *testing.T) {
+	// local. at 0, a pointer to it at 7 and nas.<pointer to local.> at 9
+	b := []byte{5, 'l', 'o', 'c', 'a', 'l', 0, 0xC0, 0, 3, 'n', 'a', 's', 0xC0, 0}
+
+	tests := []struct {
+		name     string
+		b        []byte
+		offset   int
+		expected string
+		next     int
+		err      error
+	}{
+		{name: "labels", b: b, offset: 0, expected: "local.", next: 7},
+		{name: "pointer", b: b, offset: 7, expected: "local.", next: 9},
+		{name: "labels and pointer", b: b, offset: 9, expected: "nas.local.", next: 15},
+		{name: "root", b: []byte{0}, offset: 0, expected: ".", next: 1},
+		{name: "escaped label", b: []byte{3, 'a', '.', 'b', 1, '\\', 0}, offset: 0, expected: `a\.b.\\.`, next: 7},
+		{name: "past the end", b: b, offset: len(b), err: errTruncated},
+		{name: "missing terminator", b: []byte{3, 'n', 'a', 's'}, offset: 0, err: errTruncated},
+		{name: "label past the end", b: []byte{9, 'n', 'a', 's', 0}, offset: 0, err: errTruncated},
+		{name: "half a pointer", b: []byte{3, 'n', 'a', 's', 0xC0}, offset: 0, err: errTruncated},
+		{name: "pointer past the end", b: []byte{0xC0, 0x10}, offset: 0, err: errTruncated},
+		{name: "pointer loop", b: []byte{0xC0, 0}, offset: 0, err: errors.New("mdns: too many compression pointers")},
+		{name: "pointers between each other", b: []byte{0xC0, 2, 0xC0, 0}, offset: 0, err: errors.New("mdns: too many compression pointers")},
+		{name: "reserved label type", b: []byte{0x40, 0}, offset: 0, err: errors.New("mdns: invalid label length 0x40")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, next, err := readName(test.b, test.offset)
+			if test.err != nil {
+				if err == nil || err.Error() != test.err.Error() {
+					t.Fatalf("expected error %v, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != test.expected || next != test.next {
+				t.Errorf("expected %q at %d, got %q at %d", test.expected, test.next, name, next)
+			}
+		})
+	}
+}
+
+func TestUnpackCompressed(t *testing.T) {
+	// A PTR answer whose target points back at the question name
+	b := append(header(1, 1, 0, 0),
+		5, '_', 'h', 't', 't', 'p', 4, '_', 't', 'c', 'p', 5, 'l', 'o', 'c', 'a', 'l', 0, // 12: question name
+		0, 12, 0, 1,
+		0xC0, 12, // answer name
+		0, 12, 0x80, 1, 0, 0, 0, 120, 0, 6,
+		3, 'n', 'a', 's', 0xC0, 12,
+	)
+
+	message, err := Unpack(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Record{Name: "_http._tcp.local.", Type: TypePTR, TTL: 120, CacheFlush: true, Target: "nas._http._tcp.local."}
+	if len(message.Answers) != 1 || !reflect.DeepEqual(message.Answers[0], expected) {
+		t.Errorf("expected %+v, got %+v", expected, message.Answers)
+	}
+}
+
+func TestUnpackSkipsAuthorities(t *testing.T) {
+	record := func(ip byte) []byte {
+		return []byte{1, 'a', 0, 0, 1, 0, 1, 0, 0, 0, 10, 0, 4, 10, 0, 0, ip}
+	}
+	b := header(0, 1, 1, 1)
+	b = append(b, record(1)...)
+	b = append(b, record(2)...)
+	b = append(b, record(3)...)
+
+	message, err := Unpack(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(message.Answers) != 1 || !message.Answers[0].IP.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("expected answer 10.0.0.1, got %+v", message.Answers)
+	}
+	if len(message.Additionals) != 1 || !message.Additionals[0].IP.Equal(net.IPv4(10, 0, 0, 3)) {
+		t.Errorf("expected additional 10.0.0.3, got %+v", message.Additionals)
+	}
+}
+
+func TestUnpackMalformed(t *testing.T) {
+	// name a., type, class and TTL of a record, followed by its data length
+	record := func(recordType byte, data ...byte) []byte {
+		b := []byte{1, 'a', 0, 0, recordType, 0, 1, 0, 0, 0, 10, 0, byte(len(data))}
+		return append(b, data...)
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "empty", b: []byte{}},
+		{name: "short header", b: header(0, 0, 0, 0)[:11]},
+		{name: "missing question", b: header(1, 0, 0, 0)},
+		{name: "question without type", b: append(header(1, 0, 0, 0), 1, 'a', 0, 0, 1)},
+		{name: "missing record", b: header(0, 1, 0, 0)},
+		{name: "record without data length", b: append(header(0, 1, 0, 0), 1, 'a', 0, 0, 1, 0, 1, 0, 0, 0, 10)},
+		{name: "data past the end", b: append(header(0, 1, 0, 0), append(record(byte(TypeA), 10, 0, 0, 1), 0)[:16]...)},
+		{name: "short A record", b: append(header(0, 1, 0, 0), record(byte(TypeA), 10, 0, 0)...)},
+		{name: "short AAAA record", b: append(header(0, 1, 0, 0), record(byte(TypeAAAA), 10, 0, 0, 1)...)},
+		{name: "short SRV record", b: append(header(0, 1, 0, 0), record(byte(TypeSRV), 0, 0, 0, 0, 0, 80)...)},
+		{name: "text past the data", b: append(header(0, 1, 0, 0), record(byte(TypeTXT), 5, 'a')...)},
+		{name: "PTR with a pointer loop", b: append(header(0, 1, 0, 0), record(byte(TypePTR), 0xC0, 25)...)},
+		{name: "additional missing", b: append(header(0, 1, 0, 1), record(byte(TypeA), 10, 0, 0, 1)...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if message, err := Unpack(test.b); err == nil {
+				t.Errorf("expected an error, got %+v", message)
+			}
+		})
+	}
+}
+
+func TestUnpackKeepsUnknownTypes(t *testing.T) {
+	b := append(header(0, 1, 0, 0), 1, 'a', 0, 0, 47, 0, 1, 0, 0, 0, 10, 0, 2, 0xFF, 0xFF)
+
+	message, err := Unpack(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Record{Name: "a.", Type: 47, TTL: 10}
+	if len(message.Answers) != 1 || !reflect.DeepEqual(message.Answers[0], expected) {
+		t.Errorf("expected %+v, got %+v", expected, message.Answers)
+	}
+}
+
+func FuzzUnpack(f *testing.F) {
+	packed, _ := (&Message{
+		Response:  true,
+		Questions: []Question{{Name: "_http._tcp.local.", Type: TypePTR}},
+		Answers:   []Record{{Name: "_http._tcp.local.", Type: TypePTR, TTL: 10, Target: "a._http._tcp.local."}},
+	}).Pack()
+	f.Add(packed)
+	f.Add(append(header(1, 0, 0, 0), 0xC0, 12, 0, 1, 0, 1))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		// Anything on the network must be rejected or decoded, not panic
+		_, _ = Unpack(b)
+	})
+}
+
+func TestNames(t *testing.T) {
+	name := InstanceName(`Living.room \ NAS`, "_http._tcp")
+	if name != `Living\.room \\ NAS._http._tcp.local.` {
+		t.Errorf("unexpected instance name %q", name)
+	}
+	if label := FirstLabel(name); label != `Living.room \ NAS` {
+		t.Errorf("expected the instance name back, got %q", label)
+	}
+	if label := FirstLabel(""); label != "" {
+		t.Errorf("expected no label, got %q", label)
+	}
+}
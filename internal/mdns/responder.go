@@ -0,0 +1,200 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package mdns
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// servicesName lists the service types on the network, for browsers
+	// that enumerate them.
+	servicesName = "_services._dns-sd._udp.local."
+
+	// TTLs recommended by RFC 6762 for records with and without host names
+	hostTTL  = 120
+	otherTTL = 4500
+
+	// legacyTTL caps the TTL in answers to plain DNS resolvers.
+	legacyTTL = 10
+)
+
+// Service is a service instance to advertise.
+type Service struct {
+	Instance string
+	Service  string
+	Port     int
+	Text     []string
+}
+
+// Advertise announces the service and answers queries for it until ctx is
+// done. It then tells the network the service is gone. The service runs on
+// this host, using its name and IPv4 addresses.
+func Advertise(ctx context.Context, service Service, interfaceName string) error {
+	records, err := serviceRecords(service, interfaceName)
+	if err != nil {
+		return err
+	}
+
+	conn, err := listen(interfaceName)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		// Announce twice, in case the first one got lost
+		for _, delay := range []time.Duration{0, time.Second} {
+			if !sleep(ctx, delay) {
+				break
+			}
+			_ = send(conn, &Message{Response: true, Answers: records}, groupAddress)
+		}
+
+		<-ctx.Done()
+		goodbye := []Record{}
+		for _, record := range records {
+			record.TTL = 0
+			goodbye = append(goodbye, record)
+		}
+		_ = send(conn, &Message{Response: true, Answers: goodbye}, groupAddress)
+		conn.Close()
+	}()
+
+	return readMessages(conn, func(query *Message, from *net.UDPAddr) {
+		if query.Response {
+			return
+		}
+		answer(conn, query, from, records)
+	})
+}
+
+// answer responds to the questions about the service. Queries that don't
+// come from the mDNS port are plain DNS queries, which are answered like a
+// DNS server would.
+func answer(conn *net.UDPConn, query *Message, from *net.UDPAddr, records []Record) {
+	legacy := from.Port != groupAddress.Port
+	unicast := legacy
+
+	answers := []Record{}
+	for _, question := range query.Questions {
+		for _, record := range records {
+			if (question.Type == record.Type || question.Type == TypeANY) && strings.EqualFold(question.Name, record.Name) && !containsRecord(answers, record) {
+				answers = append(answers, record)
+				unicast = unicast || question.Unicast
+			}
+		}
+	}
+	if len(answers) == 0 {
+		return
+	}
+
+	// Send the other records along, so browsers don't need to ask for them
+	additionals := []Record{}
+	for _, record := range records {
+		if record.Name != servicesName && !containsRecord(answers, record) {
+			additionals = append(additionals, record)
+		}
+	}
+
+	response := &Message{Response: true, Answers: answers, Additionals: additionals}
+	if legacy {
+		response.ID = query.ID
+		response.Questions = query.Questions
+		for _, section := range [][]Record{response.Answers, response.Additionals} {
+			for i := range section {
+				section[i].TTL = min(section[i].TTL, legacyTTL)
+				section[i].CacheFlush = false
+			}
+		}
+	}
+
+	address := groupAddress
+	if unicast {
+		address = from
+	}
+	_ = send(conn, response, address)
+}
+
+func containsRecord(records []Record, record Record) bool {
+	for _, other := range records {
+		if other.Name == record.Name && other.Type == record.Type {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serviceRecords returns the records that describe the service on this host.
+func serviceRecords(service Service, interfaceName string) ([]Record, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	host, _, _ := strings.Cut(hostname, ".")
+	host = escapeLabel(host) + ".local."
+
+	ips, err := hostIPs(interfaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := ServiceName(service.Service)
+	instanceName := InstanceName(service.Instance, service.Service)
+
+	records := []Record{
+		{Name: servicesName, Type: TypePTR, TTL: otherTTL, Target: serviceName},
+		{Name: serviceName, Type: TypePTR, TTL: otherTTL, Target: instanceName},
+		{Name: instanceName, Type: TypeSRV, TTL: hostTTL, CacheFlush: true, Target: host, Port: uint16(service.Port)},
+		{Name: instanceName, Type: TypeTXT, TTL: otherTTL, CacheFlush: true, Text: service.Text},
+	}
+	for _, ip := range ips {
+		records = append(records, Record{Name: host, Type: TypeA, TTL: hostTTL, CacheFlush: true, IP: ip})
+	}
+
+	return records, nil
+}
+
+// hostIPs returns the IPv4 addresses of the interface, or of all interfaces
+// that are up without a name.
+func hostIPs(interfaceName string) ([]net.IP, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []net.IP{}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if interfaceName != "" && iface.Name != interfaceName {
+			continue
+		}
+
+		addresses, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if prefix, ok := address.(*net.IPNet); ok && prefix.IP.To4() != nil {
+				ips = append(ips, prefix.IP.To4())
+			}
+		}
+	}
+
+	return ips, nil
+}
@@ -11,14 +11,95 @@
 
 package models
 
+import "time"
+
 type ContainerInfo struct {
+	ID       string `json:"id" koanf:"id"`
 	Name     string `json:"name" koanf:"name"`
 	Url      string `json:"url" koanf:"url"`
 	Icon     string `json:"icon" koanf:"icon"`
 	IconFile string `json:"iconFile" koanf:"-"`
 	Comment  string `json:"comment" koanf:"comment"`
+
+	Group  string   `json:"group,omitempty" koanf:"group"`
+	Tags   []string `json:"tags,omitempty" koanf:"tags"`
+	Weight int      `json:"weight,omitempty" koanf:"weight"`
+
+	Description string            `json:"description,omitempty" koanf:"description"`
+	Target      string            `json:"target,omitempty" koanf:"target"`
+	InternalUrl string            `json:"internalUrl,omitempty" koanf:"internalurl"`
+	ExternalUrl string            `json:"externalUrl,omitempty" koanf:"externalurl"`
+	Metadata    map[string]string `json:"metadata,omitempty" koanf:"metadata"`
+
+	// Status is set by sources that know whether the application is healthy.
+	Status string `json:"status,omitempty" koanf:"status"`
+
+	Visibility []VisibilityRule `json:"visibility,omitempty" koanf:"visibility"`
+
+	// Sources lists the sources (sidecar uuids or "static") that contributed
+	// to this entry. Updated is when the contributing source last reported it
+	// and Added is when HomeDash first saw it.
+	Sources []string  `json:"sources,omitempty" koanf:"-"`
+	Updated time.Time `json:"updated,omitzero" koanf:"-"`
+	Added   time.Time `json:"added,omitzero" koanf:"-"`
+}
+
+// VisibilityRule describes which visitors may see an application. A rule
+// matches when all of its non-empty criteria match: the client is on one of
+// the Networks, the request is for one of the Hosts, the Header contains one
+// of the Values (or is present at all when no Values are given) and the
+// authenticated user is one of the Users or member of one of the Groups.
+type VisibilityRule struct {
+	Networks []string `json:"networks,omitempty" koanf:"networks"`
+	Hosts    []string `json:"hosts,omitempty" koanf:"hosts"`
+	Header   string   `json:"header,omitempty" koanf:"header"`
+	Values   []string `json:"values,omitempty" koanf:"values"`
+	Users    []string `json:"users,omitempty" koanf:"users"`
+	Groups   []string `json:"groups,omitempty" koanf:"groups"`
 }
 
+// Group holds settings shared by all applications with the same group name.
+type Group struct {
+	Name       string           `json:"name" koanf:"name"`
+	Visibility []VisibilityRule `json:"visibility,omitempty" koanf:"visibility"`
+}
+
+// Dashboard is a named view on the applications, with its own title, filters
+// and layout. Empty filters don't filter. Requests for one of the Hosts get
+// this dashboard at the index.
+type Dashboard struct {
+	Name     string          `json:"name" koanf:"name"`
+	Title    string          `json:"title" koanf:"title"`
+	Hosts    []string        `json:"hosts,omitempty" koanf:"hosts"`
+	Theme    string          `json:"theme,omitempty" koanf:"theme"`
+	Apps     []string        `json:"apps,omitempty" koanf:"apps"`
+	Groups   []string        `json:"groups,omitempty" koanf:"groups"`
+	Tags     []string        `json:"tags,omitempty" koanf:"tags"`
+	Sidecars []string        `json:"sidecars,omitempty" koanf:"sidecars"`
+	Layout   DashboardLayout `json:"layout" koanf:"layout"`
+}
+
+type DashboardLayout struct {
+	Columns     int    `json:"columns,omitempty" koanf:"columns"`
+	Sort        string `json:"sort,omitempty" koanf:"sort"`
+	HideComment bool   `json:"hideComment,omitempty" koanf:"hidecomment"`
+}
+
+const (
+	TargetNewTab  = "_blank"
+	TargetSameTab = "_self"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+
+	// StatusStale marks applications of an upstream HomeDash that can't be
+	// reached, their status is unknown.
+	StatusStale = "stale"
+)
+
 type ContainerUpdate struct {
 	Uuid       string          `json:"uuid"`
 	Containers []ContainerInfo `json:"containers"`
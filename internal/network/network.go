@@ -0,0 +1,140 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+var (
+	TrustedProxies   []netip.Prefix
+	InternalNetworks []netip.Prefix
+)
+
+// Setup parses the configured network ranges.
+func Setup() error {
+	var err error
+
+	if TrustedProxies, err = ParsePrefixes(c.Config.Network.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+
+	if InternalNetworks, err = ParsePrefixes(c.Config.Network.InternalNetworks); err != nil {
+		return fmt.Errorf("invalid internal network: %w", err)
+	}
+
+	c.Logger.Debug().Any("trustedproxies", TrustedProxies).Any("internalnetworks", InternalNetworks).Msg("network ranges configured")
+
+	return nil
+}
+
+// ParsePrefixes parses a list of CIDR ranges. Single addresses are accepted
+// as well and treated as a range containing only that address.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// Contains reports whether the address is part of any of the ranges.
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoteAddr returns the address of the peer that connected to HomeDash.
+func RemoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap()
+}
+
+// IsTrustedProxy reports whether the request came directly from a trusted proxy.
+func IsTrustedProxy(r *http.Request) bool {
+	return Contains(TrustedProxies, RemoteAddr(r))
+}
+
+// ClientAddr returns the address of the client. When the request came through
+// trusted proxies, the X-Forwarded-For chain is followed from right to left
+// until the first address that is not a trusted proxy.
+func ClientAddr(r *http.Request) netip.Addr {
+	addr := RemoteAddr(r)
+	if !Contains(TrustedProxies, addr) {
+		return addr
+	}
+
+	forwarded := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+
+		addr = hop.Unmap()
+		if !Contains(TrustedProxies, addr) {
+			break
+		}
+	}
+
+	return addr
+}
+
+// IsInternalClient reports whether the client is on one of the internal networks.
+func IsInternalClient(r *http.Request) bool {
+	return Contains(InternalNetworks, ClientAddr(r))
+}
@@ -0,0 +1,192 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+// withNetworks sets the trusted proxies and internal networks for the
+// duration of a test.
+func withNetworks(t *testing.T, trustedProxies []string, internalNetworks []string) {
+	t.Helper()
+
+	previousProxies, previousNetworks := TrustedProxies, InternalNetworks
+	t.Cleanup(func() { TrustedProxies, InternalNetworks = previousProxies, previousNetworks })
+
+	var err error
+	if TrustedProxies, err = ParsePrefixes(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	if InternalNetworks, err = ParsePrefixes(internalNetworks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected []string
+		invalid  bool
+	}{
+		{name: "ranges", values: []string{"10.0.0.0/8", " 192.168.1.7/24 ", "fd00::/8"}, expected: []string{"10.0.0.0/8", "192.168.1.0/24", "fd00::/8"}},
+		{name: "addresses", values: []string{"192.168.1.1", "::ffff:172.16.0.1", "fd00::1"}, expected: []string{"192.168.1.1/32", "172.16.0.1/32", "fd00::1/128"}},
+		{name: "empty values", values: []string{"", " "}, expected: []string{}},
+		{name: "invalid address", values: []string{"10.0.0.256"}, invalid: true},
+		{name: "invalid range", values: []string{"10.0.0.0/33"}, invalid: true},
+		{name: "host name", values: []string{"proxy.local"}, invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prefixes, err := ParsePrefixes(test.values)
+			if test.invalid {
+				if err == nil {
+					t.Errorf("expected an error, got %v", prefixes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			parsed := []string{}
+			for _, prefix := range prefixes {
+				parsed = append(parsed, prefix.String())
+			}
+			if !reflect.DeepEqual(parsed, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestIsTrustedProxy(t *testing.T) {
+	withNetworks(t, []string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"}, nil)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   bool
+	}{
+		{name: "trusted range", remoteAddr: "10.1.2.3:51234", expected: true},
+		{name: "trusted address", remoteAddr: "192.168.1.1:51234", expected: true},
+		{name: "next to the trusted address", remoteAddr: "192.168.1.2:51234", expected: false},
+		{name: "IPv4-mapped IPv6", remoteAddr: "[::ffff:10.1.2.3]:51234", expected: true},
+		{name: "IPv6", remoteAddr: "[fd00::1]:443", expected: true},
+		{name: "untrusted", remoteAddr: "203.0.113.7:51234", expected: false},
+		{name: "forwarded for a trusted proxy", remoteAddr: "203.0.113.7:51234", forwarded: "10.1.2.3", expected: false},
+		{name: "without port", remoteAddr: "10.1.2.3", expected: true},
+		{name: "garbage", remoteAddr: "not an address", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			if test.forwarded != "" {
+				request.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+
+			if trusted := IsTrustedProxy(request); trusted != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, trusted)
+			}
+		})
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	withNetworks(t, []string{"10.0.0.0/8", "fd00::/8"}, nil)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:51234", expected: "203.0.113.7"},
+		{name: "spoofed by an untrusted peer", remoteAddr: "203.0.113.7:51234", forwarded: []string{"10.0.0.5"}, expected: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:51234", forwarded: []string{"198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.1:51234", expected: "10.0.0.1"},
+		{name: "spoofed leftmost entry", remoteAddr: "10.0.0.1:51234", forwarded: []string{"10.0.0.5, 198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "multiple trusted hops", remoteAddr: "10.0.0.1:51234", forwarded: []string{"198.51.100.1, 10.0.0.3,10.0.0.2"}, expected: "198.51.100.1"},
+		{name: "multiple headers", remoteAddr: "10.0.0.1:51234", forwarded: []string{"6.6.6.6, 198.51.100.1", "10.0.0.2"}, expected: "198.51.100.1"},
+		{name: "only trusted hops", remoteAddr: "10.0.0.1:51234", forwarded: []string{"10.0.0.3, 10.0.0.2"}, expected: "10.0.0.3"},
+		{name: "IPv4-mapped peer", remoteAddr: "[::ffff:10.0.0.1]:51234", forwarded: []string{"198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "IPv4-mapped hops", remoteAddr: "10.0.0.1:51234", forwarded: []string{"::ffff:198.51.100.1, ::ffff:10.0.0.2"}, expected: "198.51.100.1"},
+		{name: "IPv6", remoteAddr: "[fd00::1]:443", forwarded: []string{"2001:db8::1, fd00::2"}, expected: "2001:db8::1"},
+		{name: "garbage after the client", remoteAddr: "10.0.0.1:51234", forwarded: []string{"198.51.100.1, garbage"}, expected: "10.0.0.1"},
+		{name: "garbage before a trusted hop", remoteAddr: "10.0.0.1:51234", forwarded: []string{"garbage, 10.0.0.2"}, expected: "10.0.0.2"},
+		{name: "address with port", remoteAddr: "10.0.0.1:51234", forwarded: []string{"198.51.100.1:4000"}, expected: "10.0.0.1"},
+		{name: "empty entries", remoteAddr: "10.0.0.1:51234", forwarded: []string{"198.51.100.1,,"}, expected: "10.0.0.1"},
+		{name: "invalid peer", remoteAddr: "not an address", forwarded: []string{"198.51.100.1"}, expected: "invalid IP"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			for _, forwarded := range test.forwarded {
+				request.Header.Add("X-Forwarded-For", forwarded)
+			}
+
+			if addr := ClientAddr(request); addr.String() != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, addr)
+			}
+		})
+	}
+}
+
+func TestIsInternalClient(t *testing.T) {
+	withNetworks(t, []string{"10.0.0.1"}, []string{"192.168.0.0/16"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   bool
+	}{
+		{name: "internal", remoteAddr: "192.168.1.20:51234", expected: true},
+		{name: "external", remoteAddr: "203.0.113.7:51234", expected: false},
+		{name: "internal behind the proxy", remoteAddr: "10.0.0.1:51234", forwarded: "192.168.1.20", expected: true},
+		{name: "external behind the proxy", remoteAddr: "10.0.0.1:51234", forwarded: "203.0.113.7", expected: false},
+		{name: "spoofed by an external client", remoteAddr: "203.0.113.7:51234", forwarded: "192.168.1.20", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			if test.forwarded != "" {
+				request.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+
+			if internal := IsInternalClient(request); internal != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, internal)
+			}
+		})
+	}
+}
+
+func TestContainsUnmaps(t *testing.T) {
+	prefixes, _ := ParsePrefixes([]string{"10.0.0.0/8"})
+
+	if !Contains(prefixes, netip.MustParseAddr("::ffff:10.1.2.3")) {
+		t.Errorf("expected an IPv4-mapped address to be contained")
+	}
+}
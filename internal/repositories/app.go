@@ -12,14 +12,19 @@
 package repositories
 
 import (
+	"slices"
+
 	c "github.com/mvdkleijn/homedash/internal/config"
 	m "github.com/mvdkleijn/homedash/internal/models"
 )
 
-func GetAppList() map[string][]m.ContainerInfo {
-	appList := make(map[string][]m.ContainerInfo)
-
-	appList["static"] = c.Config.Static.Apps
+// GetStaticApps returns the applications from the config file, the apps
+// directory and the application store.
+func GetStaticApps() []m.ContainerInfo {
+	return slices.Concat(c.Config.Static.Apps, AppsDir.Apps(), Store.List())
+}
 
-	return appList
+// GetGroups returns the groups from the config file and the apps directory.
+func GetGroups() []m.Group {
+	return slices.Concat(c.Config.Groups, AppsDir.Groups())
 }
@@ -0,0 +1,255 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// reloadDelay groups the burst of events editors and tools like Ansible cause
+// when writing a file into a single reload.
+const reloadDelay = 250 * time.Millisecond
+
+// DropInFile is the result of loading a single file from the apps directory.
+type DropInFile struct {
+	Name   string            `json:"name" koanf:"-"`
+	Apps   []m.ContainerInfo `json:"-" koanf:"apps"`
+	Groups []m.Group         `json:"-" koanf:"groups"`
+	Error  string            `json:"error,omitempty" koanf:"-"`
+}
+
+// AppsDirectory loads static applications and groups from a directory of YAML
+// and JSON files, so they can be managed as separate files.
+type AppsDirectory struct {
+	mu    sync.RWMutex
+	path  string
+	files []DropInFile
+
+	// Validate checks every application in a file, so a bad file is reported
+	// when it is loaded. The prefix names the application in the error.
+	Validate func(app m.ContainerInfo, prefix string) error
+}
+
+var AppsDir = &AppsDirectory{}
+
+// SetupAppsDir loads the configured apps directory, if any.
+func SetupAppsDir() error {
+	path := c.Config.Static.AppsDir
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+
+	AppsDir.Load(path)
+
+	return nil
+}
+
+// Load reads every file in the directory. A file that can't be loaded doesn't
+// stop the others from loading, its error is kept with the file instead.
+func (d *AppsDirectory) Load(path string) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		c.Logger.Error().Err(err).Str("appsdir", path).Msg("failed to read apps directory")
+	}
+
+	files := []DropInFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isDropIn(entry.Name()) {
+			continue
+		}
+
+		dropIn := d.loadDropIn(filepath.Join(path, entry.Name()))
+		if dropIn.Error != "" {
+			c.Logger.Error().Str("file", dropIn.Name).Str("error", dropIn.Error).Msg("failed to load apps file")
+		}
+		files = append(files, dropIn)
+	}
+
+	d.mu.Lock()
+	d.path = path
+	d.files = files
+	d.mu.Unlock()
+
+	c.Logger.Info().Str("appsdir", path).Int("files", len(files)).Msg("loaded apps directory")
+}
+
+// Watch reloads the directory whenever a file in it changes, until ctx is
+// done. The onChange function is called after every reload.
+func (d *AppsDirectory) Watch(ctx context.Context, onChange func()) error {
+	d.mu.RLock()
+	path := d.path
+	d.mu.RUnlock()
+
+	if path == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(path); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !isDropIn(event.Name) {
+					continue
+				}
+
+				c.Logger.Debug().Str("file", event.Name).Str("op", event.Op.String()).Msg("apps file changed")
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, func() {
+					d.Load(path)
+					onChange()
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				c.Logger.Error().Err(err).Str("appsdir", path).Msg("error watching apps directory")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Files returns the loaded files and their errors.
+func (d *AppsDirectory) Files() []DropInFile {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return slices.Clone(d.files)
+}
+
+// Apps returns the applications of all files that loaded successfully, with
+// up to date icon paths.
+func (d *AppsDirectory) Apps() []m.ContainerInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	apps := []m.ContainerInfo{}
+	for _, dropIn := range d.files {
+		for _, app := range dropIn.Apps {
+			app.IconFile = c.GetIconPath(app.Icon)
+			apps = append(apps, app)
+		}
+	}
+
+	return apps
+}
+
+// Groups returns the groups of all files that loaded successfully.
+func (d *AppsDirectory) Groups() []m.Group {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	groups := []m.Group{}
+	for _, dropIn := range d.files {
+		groups = append(groups, dropIn.Groups...)
+	}
+
+	return groups
+}
+
+func (d *AppsDirectory) loadDropIn(path string) DropInFile {
+	dropIn := DropInFile{Name: filepath.Base(path)}
+
+	// JSON is valid YAML, so the YAML parser reads both
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+		dropIn.Error = err.Error()
+		return dropIn
+	}
+
+	if err := k.Unmarshal("", &dropIn); err != nil {
+		dropIn.Error = err.Error()
+		return dropIn
+	}
+
+	for i, app := range dropIn.Apps {
+		if app.Name == "" || app.Url == "" {
+			dropIn.Error = fmt.Sprintf("apps[%d]: name and url are required", i)
+			dropIn.Apps = nil
+			dropIn.Groups = nil
+			return dropIn
+		}
+
+		if d.Validate != nil {
+			if err := d.Validate(app, fmt.Sprintf("apps[%d].", i)); err != nil {
+				dropIn.Error = err.Error()
+				dropIn.Apps = nil
+				dropIn.Groups = nil
+				return dropIn
+			}
+		}
+	}
+
+	for i, group := range dropIn.Groups {
+		if group.Name == "" {
+			dropIn.Error = fmt.Sprintf("groups[%d]: name is required", i)
+			dropIn.Apps = nil
+			dropIn.Groups = nil
+			return dropIn
+		}
+	}
+
+	return dropIn
+}
+
+func isDropIn(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yml", ".yaml", ".json":
+		return !strings.HasPrefix(filepath.Base(name), ".")
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package repositories_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/repositories"
+	"github.com/mvdkleijn/homedash/internal/services"
+)
+
+func TestAppsDirRejectsInvalidApps(t *testing.T) {
+	logger := zerolog.Nop()
+	c.Logger = &logger
+	c.Config.API.AllowedSchemes = []string{"http", "https"}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.yml":   "apps:\n  - name: Jellyfin\n    url: https://jellyfin.example.com\n",
+		"script.yml": "apps:\n  - name: Evil\n    url: javascript:alert(1)\n",
+		"long.yml":   "apps:\n  - name: Jellyfin\n    url: https://jellyfin.example.com\n    tags: [ " + "a, a, a, a, a, a, a, a, a, a, a, a, a, a, a, a, a, a, a, a, a" + " ]\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appsDir := &repositories.AppsDirectory{Validate: services.ValidateApp}
+	appsDir.Load(dir)
+
+	for _, file := range appsDir.Files() {
+		switch file.Name {
+		case "good.yml":
+			if file.Error != "" {
+				t.Errorf("expected good.yml to load, got %s", file.Error)
+			}
+		default:
+			if file.Error == "" {
+				t.Errorf("expected %s to be rejected", file.Name)
+			}
+		}
+	}
+
+	if apps := appsDir.Apps(); len(apps) != 1 || apps[0].Name != "Jellyfin" {
+		t.Errorf("expected only the valid application, got %v", apps)
+	}
+}
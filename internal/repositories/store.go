@@ -0,0 +1,202 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package repositories
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+var (
+	ErrAppNotFound = errors.New("application not found")
+	ErrAppExists   = errors.New("application already exists")
+)
+
+// AppStore keeps the static applications managed through the admin API. They
+// are persisted to their own file so the user's config file is never touched.
+type AppStore struct {
+	mu   sync.RWMutex
+	path string
+	apps []m.ContainerInfo
+}
+
+var Store = &AppStore{}
+
+// SetupStore loads the applications from the configured store file. A missing
+// file is not an error, it is created on the first change.
+func SetupStore() error {
+	return Store.Load(c.Config.Static.StoreFile)
+}
+
+func (s *AppStore) Load(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+	s.apps = []m.ContainerInfo{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &s.apps); err != nil {
+		return err
+	}
+
+	c.Logger.Info().Str("storefile", path).Int("apps", len(s.apps)).Msg("loaded application store")
+
+	return nil
+}
+
+// List returns the stored applications with up to date icon paths.
+func (s *AppStore) List() []m.ContainerInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	apps := slices.Clone(s.apps)
+	for i := range apps {
+		apps[i].IconFile = c.GetIconPath(apps[i].Icon)
+	}
+
+	return apps
+}
+
+func (s *AppStore) Get(id string) (m.ContainerInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return m.ContainerInfo{}, ErrAppNotFound
+	}
+
+	app := s.apps[i]
+	app.IconFile = c.GetIconPath(app.Icon)
+
+	return app, nil
+}
+
+// Create adds the application, generating an ID when it has none.
+func (s *AppStore) Create(app m.ContainerInfo) (m.ContainerInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if app.ID == "" {
+		app.ID = newID()
+	}
+
+	if s.indexOf(app.ID) >= 0 {
+		return app, ErrAppExists
+	}
+
+	app.IconFile = ""
+	previous := slices.Clone(s.apps)
+	s.apps = append(s.apps, app)
+
+	return s.withIconFile(app), s.saveOrRevert(previous)
+}
+
+// Update replaces the application with the given ID.
+func (s *AppStore) Update(id string, app m.ContainerInfo) (m.ContainerInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return app, ErrAppNotFound
+	}
+
+	app.ID = id
+	app.IconFile = ""
+	previous := slices.Clone(s.apps)
+	s.apps[i] = app
+
+	return s.withIconFile(app), s.saveOrRevert(previous)
+}
+
+func (s *AppStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return ErrAppNotFound
+	}
+
+	previous := slices.Clone(s.apps)
+	s.apps = slices.Delete(s.apps, i, i+1)
+
+	return s.saveOrRevert(previous)
+}
+
+// indexOf must be called with the lock held.
+func (s *AppStore) indexOf(id string) int {
+	return slices.IndexFunc(s.apps, func(app m.ContainerInfo) bool {
+		return app.ID == id
+	})
+}
+
+func (s *AppStore) withIconFile(app m.ContainerInfo) m.ContainerInfo {
+	app.IconFile = c.GetIconPath(app.Icon)
+	return app
+}
+
+// saveOrRevert saves the store and restores the previous applications when
+// that fails, so memory and file don't drift apart.
+func (s *AppStore) saveOrRevert(previous []m.ContainerInfo) error {
+	if err := s.save(); err != nil {
+		s.apps = previous
+		return err
+	}
+
+	return nil
+}
+
+// save writes the store to a temporary file first, so a crash can't leave a
+// half written store behind. Must be called with the lock held.
+func (s *AppStore) save() error {
+	data, err := json.MarshalIndent(s.apps, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmpFile := s.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, s.path)
+}
+
+func newID() string {
+	data := make([]byte, 6)
+	rand.Read(data)
+
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,259 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/importer"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	"github.com/mvdkleijn/homedash/internal/repositories"
+	s "github.com/mvdkleijn/homedash/internal/services"
+)
+
+const maxIconResults = 50
+
+// importMediaTypes are the content types accepted for imported files.
+var importMediaTypes = []string{
+	"application/json",
+	"application/yaml", "application/x-yaml", "text/yaml",
+	"application/vnd.sqlite3", "application/x-sqlite3", "application/octet-stream",
+}
+
+type icon struct {
+	Name     string `json:"name"`
+	IconFile string `json:"iconFile"`
+}
+
+func (v *V1) GetIcons(w http.ResponseWriter, r *http.Request) {
+	icons := []icon{}
+	for _, name := range c.SearchIcons(r.URL.Query().Get("q"), maxIconResults) {
+		icons = append(icons, icon{Name: name, IconFile: c.GetIconPath(name)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(icons)
+}
+
+func (v *V1) GetStoredApps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(repositories.Store.List())
+}
+
+func (v *V1) GetStoredApp(w http.ResponseWriter, r *http.Request) {
+	app, err := repositories.Store.Get(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(app)
+}
+
+func (v *V1) PostStoredApp(w http.ResponseWriter, r *http.Request) {
+	app, ok := readApp(w, r)
+	if !ok {
+		return
+	}
+
+	app, err := repositories.Store.Create(app)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	DataStore.Touch()
+	c.Logger.Info().Str("id", app.ID).Str("name", app.Name).Msg("created static application")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/admin/apps/"+app.ID)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(app)
+}
+
+func (v *V1) PutStoredApp(w http.ResponseWriter, r *http.Request) {
+	app, ok := readApp(w, r)
+	if !ok {
+		return
+	}
+
+	app, err := repositories.Store.Update(r.PathValue("id"), app)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	DataStore.Touch()
+	c.Logger.Info().Str("id", app.ID).Str("name", app.Name).Msg("updated static application")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(app)
+}
+
+func (v *V1) DeleteStoredApp(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := repositories.Store.Delete(id); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	DataStore.Touch()
+	c.Logger.Info().Str("id", id).Msg("deleted static application")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAppsDir lists the files in the apps directory and why they failed to load.
+func (v *V1) GetAppsDir(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(repositories.AppsDir.Files())
+}
+
+// GetSources lists the application sources and whether they work.
+func (v *V1) GetSources(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(DataStore.SourcesHealth())
+}
+
+// PostImport converts the configuration of another dashboard, sent as the
+// request body. With save=true, the applications are added to the store.
+func (v *V1) PostImport(w http.ResponseWriter, r *http.Request) {
+	if !hasMediaType(r, importMediaTypes...) {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "the content type must be JSON, YAML or a SQLite database", nil)
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	switch format {
+	case "", importer.FormatHomer, importer.FormatHomepage, importer.FormatDashy, importer.FormatHeimdall:
+	default:
+		writeProblem(w, r, http.StatusBadRequest, importer.ErrUnknownFormat.Error(), nil)
+		return
+	}
+
+	save, err := strconv.ParseBool(query.Get("save"))
+	if err != nil && query.Get("save") != "" {
+		writeProblem(w, r, http.StatusBadRequest, "invalid save parameter, expected true or false", nil)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, c.Config.API.MaxBodySize))
+	if err != nil {
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, "the file is too large", nil)
+		return
+	}
+
+	result, err := importer.Convert(format, data)
+	if err != nil {
+		writeProblem(w, r, http.StatusUnprocessableEntity, err.Error(), nil)
+		return
+	}
+
+	// Skip what HomeDash would refuse, like URLs with other schemes
+	apps := []m.ContainerInfo{}
+	for _, app := range result.Apps {
+		if invalid := s.ValidateContainer(app, "", c.Config.API.AllowedSchemes); len(invalid) > 0 {
+			result.Unmapped = append(result.Unmapped, importer.UnmappedItem{Item: app.Name, Reason: fmt.Sprintf("skipped, %s %s", invalid[0].Field, invalid[0].Reason)})
+			continue
+		}
+		apps = append(apps, app)
+	}
+	result.Apps = apps
+
+	if save {
+		for i, app := range result.Apps {
+			created, err := repositories.Store.Create(app)
+			if err != nil {
+				// The applications before this one were saved
+				DataStore.Touch()
+				writeStoreError(w, r, err)
+				return
+			}
+			result.Apps[i] = created
+		}
+
+		DataStore.Touch()
+		c.Logger.Info().Str("format", result.Format).Int("apps", len(result.Apps)).Msg("imported static applications")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// readApp reads and validates an application from the request body. It writes
+// a problem response and returns false when that fails.
+func readApp(w http.ResponseWriter, r *http.Request) (m.ContainerInfo, bool) {
+	var app m.ContainerInfo
+
+	if !hasMediaType(r, "application/json") {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "the content type must be application/json", nil)
+		return app, false
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, c.Config.API.MaxBodySize)).Decode(&app); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid JSON payload", nil)
+		return app, false
+	}
+
+	if invalid := s.ValidateContainer(app, "", c.Config.API.AllowedSchemes); len(invalid) > 0 {
+		writeProblem(w, r, http.StatusUnprocessableEntity, "the application contains invalid fields", invalid)
+		return app, false
+	}
+
+	// These are determined by HomeDash, not by the client
+	app.Sources = nil
+	app.Updated = time.Time{}
+	app.Added = time.Time{}
+
+	return app, true
+}
+
+// hasMediaType reports whether the request body has one of the media types.
+// Browsers only send other types than forms and text/plain from another site
+// after a CORS preflight, so this keeps other sites from using the session of
+// an admin.
+func hasMediaType(r *http.Request, mediaTypes ...string) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && slices.Contains(mediaTypes, mediaType)
+}
+
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrAppNotFound):
+		writeProblem(w, r, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, repositories.ErrAppExists):
+		writeProblem(w, r, http.StatusConflict, err.Error(), nil)
+	default:
+		c.Logger.Error().Err(err).Msg("failed to update the application store")
+		writeProblem(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to update the application store: %v", err), nil)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+func TestMain(tests *testing.M) {
+	logger := zerolog.Nop()
+	c.Logger = &logger
+	c.Config.API.AllowedSchemes = []string{"http", "https"}
+	c.Config.API.MaxBodySize = 1 << 20
+
+	os.Exit(tests.Run())
+}
+
+func TestReadAppContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+	}{
+		{name: "json", contentType: "application/json", status: http.StatusOK},
+		{name: "json with charset", contentType: "application/json; charset=utf-8", status: http.StatusOK},
+		{name: "text", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "form", contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{name: "multipart form", contentType: "multipart/form-data; boundary=x", status: http.StatusUnsupportedMediaType},
+		{name: "missing", contentType: "", status: http.StatusUnsupportedMediaType},
+		{name: "invalid", contentType: "application/json; charset", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/apps", strings.NewReader(`{"name": "Jellyfin", "url": "https://jf.example.com"}`))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			recorder := httptest.NewRecorder()
+
+			app, ok := readApp(recorder, request)
+			if test.status == http.StatusOK {
+				if !ok || app.Name != "Jellyfin" {
+					t.Errorf("expected the application, got %+v and status %d", app, recorder.Code)
+				}
+				return
+			}
+
+			if ok || recorder.Code != test.status {
+				t.Errorf("expected status %d, got %d", test.status, recorder.Code)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("expected a problem, got %q", contentType)
+			}
+		})
+	}
+}
+
+func TestPostImportContentType(t *testing.T) {
+	homer := "services:\n  - name: Media\n    items:\n      - name: Jellyfin\n        url: https://jf.example.com\n"
+
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+	}{
+		{name: "yaml", contentType: "application/yaml", status: http.StatusOK},
+		{name: "text yaml", contentType: "text/yaml; charset=utf-8", status: http.StatusOK},
+		{name: "binary", contentType: "application/octet-stream", status: http.StatusOK},
+		{name: "text", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "form", contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{name: "multipart form", contentType: "multipart/form-data; boundary=x", status: http.StatusUnsupportedMediaType},
+		{name: "missing", contentType: "", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/import?format=homer", strings.NewReader(homer))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			recorder := httptest.NewRecorder()
+
+			(&V1{}).PostImport(recorder, request)
+			if recorder.Code != test.status {
+				t.Fatalf("expected status %d, got %d: %s", test.status, recorder.Code, recorder.Body)
+			}
+			if test.status == http.StatusOK && !strings.Contains(recorder.Body.String(), "Jellyfin") {
+				t.Errorf("expected the converted application, got %s", recorder.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package routes
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/mvdkleijn/homedash/internal/auth"
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// requireRole only lets users with the role through to the handler.
+func requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity := auth.FromContext(r.Context())
+		if !auth.Authorize(identity, role) {
+			status := http.StatusForbidden
+			if identity == nil {
+				status = http.StatusUnauthorized
+			}
+			writeProblem(w, r, status, "this operation requires the "+role+" role", nil)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// RequireLogin sends anonymous users to the login page when the built-in
+// OpenID Connect login is enabled and anonymous users lack the role.
+func RequireLogin(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c.Config.Auth.OIDC.Enabled && auth.FromContext(r.Context()) == nil && !auth.Authorize(nil, role) {
+			http.Redirect(w, r, "/auth/login?redirect="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/mvdkleijn/homedash/internal/auth"
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// OIDC serves the login flow of the built-in OpenID Connect support.
+type OIDC struct {
+	Provider *auth.OIDCProvider
+}
+
+func (o *OIDC) AddRoutes(mux *http.ServeMux) error {
+	mux.HandleFunc("GET /auth/login", o.Login)
+	mux.HandleFunc("GET /auth/callback", o.Callback)
+	mux.HandleFunc("GET /auth/logout", o.Logout)
+
+	return nil
+}
+
+func (o *OIDC) Login(w http.ResponseWriter, r *http.Request) {
+	if err := o.Provider.Login(w, r, r.URL.Query().Get("redirect")); err != nil {
+		c.Logger.Error().Err(err).Msg("failed to start login")
+		writeProblem(w, r, http.StatusBadGateway, "the identity provider is unavailable", nil)
+	}
+}
+
+func (o *OIDC) Callback(w http.ResponseWriter, r *http.Request) {
+	identity, redirect, err := o.Provider.Callback(w, r)
+	if err != nil {
+		c.Logger.Warn().Err(err).Msg("login failed")
+		writeProblem(w, r, http.StatusUnauthorized, "login failed", nil)
+		return
+	}
+
+	if err := auth.StartSession(w, r, identity); err != nil {
+		c.Logger.Error().Err(err).Msg("failed to start session")
+		writeProblem(w, r, http.StatusInternalServerError, "failed to start session", nil)
+		return
+	}
+
+	c.Logger.Info().Str("user", identity.User).Msg("user logged in")
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (o *OIDC) Logout(w http.ResponseWriter, r *http.Request) {
+	auth.EndSession(w, r)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
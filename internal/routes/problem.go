@@ -0,0 +1,45 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	s "github.com/mvdkleijn/homedash/internal/services"
+)
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []s.FieldError `json:"invalid-params,omitempty"`
+}
+
+// writeProblem responds with an application/problem+json document.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, invalidParams []s.FieldError) {
+	problem := Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      r.URL.Path,
+		InvalidParams: invalidParams,
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -12,38 +12,69 @@
 package routes
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/mvdkleijn/homedash/internal/auth"
 	c "github.com/mvdkleijn/homedash/internal/config"
 	m "github.com/mvdkleijn/homedash/internal/models"
+	"github.com/mvdkleijn/homedash/internal/network"
 	s "github.com/mvdkleijn/homedash/internal/services"
 )
 
-var DataStore = s.DataStore{
-	LastUpdated: map[string]time.Time{},
-	Containers:  make(map[string][]m.ContainerInfo),
-}
+var (
+	DataStore = s.NewDataStore()
+	Sidecars  = s.NewSidecarStore()
+)
+
+// maxLongPollWait caps how long a client may ask GetApplications to wait for
+// changes using the wait parameter.
+const maxLongPollWait = 2 * time.Minute
 
 type V1 struct{}
 
 func (v *V1) AddRoutes(mux *http.ServeMux) error {
-	mux.HandleFunc("POST /api/v1/applications", v.PostApplications)
-	mux.HandleFunc("GET /api/v1/applications", v.GetApplications)
-	mux.HandleFunc("GET /api/v1/sidecars", v.GetSidecars)
+	mux.HandleFunc("POST /api/v1/applications", requireRole(auth.RolePublisher, v.PostApplications))
+	mux.HandleFunc("GET /api/v1/applications", requireRole(auth.RoleViewer, v.GetApplications))
+	mux.HandleFunc("GET /api/v1/sidecars", requireRole(auth.RoleViewer, v.GetSidecars))
+	mux.HandleFunc("GET /api/v1/dashboard", requireRole(auth.RoleViewer, v.GetHostDashboard))
+	mux.HandleFunc("GET /api/v1/dashboards", requireRole(auth.RoleViewer, v.GetDashboards))
+	mux.HandleFunc("GET /api/v1/dashboards/{name}", requireRole(auth.RoleViewer, v.GetDashboard))
+	mux.HandleFunc("GET /api/v1/dashboards/{name}/applications", requireRole(auth.RoleViewer, v.GetDashboardApplications))
 	mux.HandleFunc("GET /api/v1/status", v.GetStatus)
 	mux.HandleFunc("HEAD /api/v1/status", v.HeadStatus)
+	mux.HandleFunc("GET /api/v1/me", v.GetMe)
+
+	// Admin API
+	mux.HandleFunc("DELETE /api/v1/sidecars/{uuid}", requireRole(auth.RoleAdmin, v.DeleteSidecar))
+	mux.HandleFunc("POST /api/v1/icons/refresh", requireRole(auth.RoleAdmin, v.RefreshIcons))
+	mux.HandleFunc("GET /api/v1/icons", requireRole(auth.RoleAdmin, v.GetIcons))
+	mux.HandleFunc("GET /api/v1/admin/apps", requireRole(auth.RoleAdmin, v.GetStoredApps))
+	mux.HandleFunc("POST /api/v1/admin/apps", requireRole(auth.RoleAdmin, v.PostStoredApp))
+	mux.HandleFunc("GET /api/v1/admin/apps/{id}", requireRole(auth.RoleAdmin, v.GetStoredApp))
+	mux.HandleFunc("PUT /api/v1/admin/apps/{id}", requireRole(auth.RoleAdmin, v.PutStoredApp))
+	mux.HandleFunc("DELETE /api/v1/admin/apps/{id}", requireRole(auth.RoleAdmin, v.DeleteStoredApp))
+	mux.HandleFunc("GET /api/v1/admin/appsdir", requireRole(auth.RoleAdmin, v.GetAppsDir))
+	mux.HandleFunc("GET /api/v1/admin/sources", requireRole(auth.RoleAdmin, v.GetSources))
+	mux.HandleFunc("POST /api/v1/admin/import", requireRole(auth.RoleAdmin, v.PostImport))
 
 	return nil
 }
 
 func (v *V1) GetSidecars(w http.ResponseWriter, r *http.Request) {
-	sidecars := DataStore.GetSidecarList()
+	sidecars := Sidecars.GetSidecarList()
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -52,6 +83,53 @@ func (v *V1) GetSidecars(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (v *V1) DeleteSidecar(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+
+	if !slices.Contains(Sidecars.GetSidecarList(), uuid) {
+		writeProblem(w, r, http.StatusNotFound, "unknown sidecar", nil)
+		return
+	}
+
+	Sidecars.DeleteAllEntries(uuid)
+	c.Logger.Info().Str("uuid", uuid).Msg("deleted sidecar entries")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (v *V1) RefreshIcons(w http.ResponseWriter, r *http.Request) {
+	// Downloading the icons takes a while, so don't keep the client waiting.
+	go func() {
+		c.UpdateIcons(true)
+		c.UpdateIconPaths()
+		DataStore.Touch()
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// GetMe returns the identity of the current user, or 204 when anonymous.
+func (v *V1) GetMe(w http.ResponseWriter, r *http.Request) {
+	identity := auth.FromContext(r.Context())
+	if identity == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	logout := ""
+	if c.Config.Auth.OIDC.Enabled && auth.SessionIdentity(r) != nil {
+		logout = "/auth/logout"
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		*auth.Identity
+		Admin  bool   `json:"admin"`
+		Logout string `json:"logout,omitempty"`
+	}{identity, auth.IsAdmin(identity), logout})
+}
+
 func (v *V1) GetStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -66,32 +144,210 @@ func (v *V1) HeadStatus(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (v *V1) GetApplications(w http.ResponseWriter, r *http.Request) {
-	containerList := DataStore.GetContainerList()
+func (v *V1) GetDashboards(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s.GetDashboards())
+}
+
+func (v *V1) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard, exists := s.GetDashboard(r.PathValue("name"))
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, "unknown dashboard", nil)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(containerList)
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+// GetHostDashboard returns the dashboard profile for the requested host, or
+// 204 when the host has no profile.
+func (v *V1) GetHostDashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard, exists := s.GetDashboardForHost(s.NewVisitor(r))
+	if !exists {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+func (v *V1) GetDashboardApplications(w http.ResponseWriter, r *http.Request) {
+	dashboard, exists := s.GetDashboard(r.PathValue("name"))
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, "unknown dashboard", nil)
+		return
+	}
+
+	v.writeApplications(w, r, &dashboard)
+}
+
+// GetApplications returns the applications, limited to the dashboard profile
+// of the requested host if it has one.
+func (v *V1) GetApplications(w http.ResponseWriter, r *http.Request) {
+	if dashboard, exists := s.GetDashboardForHost(s.NewVisitor(r)); exists {
+		v.writeApplications(w, r, &dashboard)
+		return
+	}
+
+	v.writeApplications(w, r, nil)
+}
+
+// writeApplications responds with the applications requested by the query
+// parameters, limited to the dashboard when one is given.
+func (v *V1) writeApplications(w http.ResponseWriter, r *http.Request, dashboard *m.Dashboard) {
+	query, err := parseAppQuery(r)
+	query.Dashboard = dashboard
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// The list depends on who asks and from where, not only on the data
+	w.Header().Set("Vary", "Cookie, Authorization, X-API-Key, Host")
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+
+	for {
+		// Read the version first, so a change while encoding isn't missed
+		version := DataStore.Version()
+
+		containerList, total := DataStore.QueryContainerList(query)
+		containerList = s.SelectUrls(containerList, network.IsInternalClient(r))
+
+		body, err := json.Marshal(containerList)
+		if err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, "could not encode the applications", nil)
+			return
+		}
+		etag := bodyETag(body, total)
+
+		// Wait for a change that alters this client's list, or until the
+		// wait time is up
+		if etagMatches(r.Header.Get("If-None-Match"), etag) && ctx.Err() == nil && wait > 0 {
+			DataStore.WaitForChange(ctx, version)
+			continue
+		}
+
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		w.WriteHeader(http.StatusOK)
+		w.Write(append(body, '\n'))
+		return
+	}
+}
+
+// parseAppQuery reads the filter, sort and pagination parameters for the
+// applications list from the query string.
+func parseAppQuery(r *http.Request) (s.AppQuery, error) {
+	params := r.URL.Query()
+
+	query := s.AppQuery{
+		Group:   params.Get("group"),
+		Tag:     params.Get("tag"),
+		Sidecar: params.Get("sidecar"),
+		Source:  params.Get("source"),
+		Search:  params.Get("q"),
+		Sort:    params.Get("sort"),
+		Visitor: s.NewVisitor(r),
+	}
+
+	var err error
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return query, fmt.Errorf("invalid limit %q", limit)
+		}
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			return query, fmt.Errorf("invalid offset %q", offset)
+		}
+	}
+
+	return query, query.Validate()
+}
+
+// parseWait reads the optional long-polling duration, e.g. "30s".
+func parseWait(r *http.Request) (time.Duration, error) {
+	param := r.URL.Query().Get("wait")
+	if param == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(param)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid wait %q", param)
+	}
+
+	return min(wait, maxLongPollWait), nil
+}
+
+// bodyETag derives the ETag from the response itself, which differs per
+// visitor because of visibility rules, dashboards and internal URLs.
+func bodyETag(body []byte, total int) string {
+	hash := sha256.New()
+	hash.Write(body)
+	fmt.Fprintf(hash, "|%d", total)
+
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString(hash.Sum(nil))[:16])
+}
+
+// etagMatches reports whether an If-None-Match header matches the etag.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag || "W/"+candidate == etag {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (v *V1) PostApplications(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, c.Config.API.MaxBodySize))
 	if err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			writeProblem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("payload must not be larger than %d bytes", maxBytesError.Limit), nil)
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, "could not read payload", nil)
 		return
 	}
 
 	var containerUpdate m.ContainerUpdate
 	err = json.Unmarshal(body, &containerUpdate)
 	if err != nil {
-		http.Error(w, "invalid JSON payload", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid JSON payload", nil)
 		return
 	}
 
-	if containerUpdate.Uuid == "" {
-		errorMsg := "missing uuid in payload"
-		c.Logger.Warn().Str("uuid", errorMsg)
-		http.Error(w, errorMsg, http.StatusUnprocessableEntity)
+	if invalid := s.ValidateContainerUpdate(containerUpdate, c.Config.API.MaxAppsPerSidecar, c.Config.API.AllowedSchemes); invalid != nil {
+		c.Logger.Warn().Str("uuid", containerUpdate.Uuid).Int("errors", len(invalid)).Msg("rejected invalid payload")
+		writeProblem(w, r, http.StatusUnprocessableEntity, "the payload contains invalid fields", invalid)
 		return
 	}
 
@@ -103,7 +359,7 @@ func (v *V1) PostApplications(w http.ResponseWriter, r *http.Request) {
 		containerUpdate.Containers[i].IconFile = c.GetIconPath(containerUpdate.Containers[i].Icon)
 	}
 
-	DataStore.AddEntries(containerUpdate.Uuid, containerUpdate.Containers)
+	Sidecars.AddEntries(containerUpdate.Uuid, containerUpdate.Containers)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -0,0 +1,335 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	"github.com/mvdkleijn/homedash/internal/network"
+	s "github.com/mvdkleijn/homedash/internal/services"
+)
+
+// testSource provides applications that tests can change.
+type testSource struct {
+	mu       sync.Mutex
+	apps     []m.ContainerInfo
+	onChange func()
+}
+
+func (source *testSource) Name() string  { return "test" }
+func (source *testSource) Health() error { return nil }
+
+func (source *testSource) List() []m.ContainerInfo {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	return append([]m.ContainerInfo{}, source.apps...)
+}
+
+func (source *testSource) Watch(ctx context.Context, onChange func()) error {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	source.onChange = onChange
+	return nil
+}
+
+// set replaces the applications and reports the change.
+func (source *testSource) set(apps ...m.ContainerInfo) {
+	source.mu.Lock()
+	source.apps = apps
+	onChange := source.onChange
+	source.mu.Unlock()
+
+	onChange()
+}
+
+// withApplications replaces the DataStore with one holding only the
+// applications of a test source for the duration of a test.
+func withApplications(t *testing.T, apps ...m.ContainerInfo) *testSource {
+	t.Helper()
+
+	previous := DataStore
+	DataStore = s.NewDataStore()
+	t.Cleanup(func() { DataStore = previous })
+
+	source := &testSource{}
+	if err := DataStore.AddSource(context.Background(), source); err != nil {
+		t.Fatal(err)
+	}
+	source.set(apps...)
+
+	return source
+}
+
+var (
+	jellyfin = m.ContainerInfo{Name: "Jellyfin", Url: "https://jellyfin.example.com", InternalUrl: "http://10.0.0.5:8096", Group: "Media", Sources: []string{"test:default"}}
+	gitea    = m.ContainerInfo{Name: "Gitea", Url: "https://git.example.com", Group: "Code", Sources: []string{"test:default"}}
+)
+
+// getApplications requests the applications like a client on the internet.
+func getApplications(target string, ifNoneMatch string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request.RemoteAddr = "203.0.113.7:51234"
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	recorder := httptest.NewRecorder()
+	(&V1{}).GetApplications(recorder, request)
+
+	return recorder
+}
+
+func TestGetApplicationsETag(t *testing.T) {
+	withApplications(t, jellyfin, gitea)
+
+	response := getApplications("/api/v1/applications", "")
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", response.Code)
+	}
+
+	// The ETag is derived from the body and the total
+	etag := response.Header().Get("ETag")
+	if expected := bodyETag(bytes.TrimSuffix(response.Body.Bytes(), []byte("\n")), 2); etag != expected {
+		t.Errorf("expected ETag %s, got %s", expected, etag)
+	}
+	if vary := response.Header().Get("Vary"); vary != "Cookie, Authorization, X-API-Key, Host" {
+		t.Errorf("expected the list to vary by who asks, got %q", vary)
+	}
+	if total := response.Header().Get("X-Total-Count"); total != "2" {
+		t.Errorf("expected a total of 2, got %q", total)
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "same ETag", target: "/api/v1/applications", ifNoneMatch: etag, status: http.StatusNotModified},
+		{name: "strong form of the ETag", target: "/api/v1/applications", ifNoneMatch: strings.TrimPrefix(etag, "W/"), status: http.StatusNotModified},
+		{name: "one of several ETags", target: "/api/v1/applications", ifNoneMatch: `W/"other", ` + etag, status: http.StatusNotModified},
+		{name: "any ETag", target: "/api/v1/applications", ifNoneMatch: "*", status: http.StatusNotModified},
+		{name: "other ETag", target: "/api/v1/applications", ifNoneMatch: `W/"other"`, status: http.StatusOK},
+		{name: "other query", target: "/api/v1/applications?group=Media", ifNoneMatch: etag, status: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := getApplications(test.target, test.ifNoneMatch)
+			if response.Code != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, response.Code)
+			}
+			if response.Header().Get("Vary") == "" {
+				t.Errorf("expected a Vary header")
+			}
+
+			if test.status == http.StatusNotModified {
+				if response.Body.Len() != 0 || response.Header().Get("ETag") != etag {
+					t.Errorf("expected an empty body and ETag %s, got %q and %s", etag, response.Body, response.Header().Get("ETag"))
+				}
+				return
+			}
+			if response.Header().Get("ETag") == "" {
+				t.Errorf("expected an ETag")
+			}
+		})
+	}
+}
+
+func TestGetApplicationsETagChanges(t *testing.T) {
+	source := withApplications(t, jellyfin, gitea)
+	etag := getApplications("/api/v1/applications", "").Header().Get("ETag")
+
+	// The ETag follows the data
+	renamed := gitea
+	renamed.Name = "Forgejo"
+	source.set(jellyfin, renamed)
+	response := getApplications("/api/v1/applications", etag)
+	if response.Code != http.StatusOK || response.Header().Get("ETag") == etag {
+		t.Errorf("expected a new list with a new ETag, got status %d and %s", response.Code, response.Header().Get("ETag"))
+	}
+
+	// and the client, as internal clients get internal URLs
+	previous := network.InternalNetworks
+	network.InternalNetworks, _ = network.ParsePrefixes([]string{"192.168.0.0/16"})
+	t.Cleanup(func() { network.InternalNetworks = previous })
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+	request.RemoteAddr = "192.168.1.20:51234"
+	request.Header.Set("If-None-Match", response.Header().Get("ETag"))
+	internal := httptest.NewRecorder()
+	(&V1{}).GetApplications(internal, request)
+
+	if internal.Code != http.StatusOK || !strings.Contains(internal.Body.String(), "http://10.0.0.5:8096") {
+		t.Errorf("expected the internal URL, got status %d and %s", internal.Code, internal.Body)
+	}
+}
+
+func TestGetApplicationsWait(t *testing.T) {
+	source := withApplications(t, jellyfin, gitea)
+	etag := getApplications("/api/v1/applications?group=Media", "").Header().Get("ETag")
+
+	responses := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		responses <- getApplications("/api/v1/applications?group=Media&wait=10s", etag)
+	}()
+
+	// Changes that don't alter the list keep the request waiting
+	renamed := gitea
+	renamed.Name = "Forgejo"
+	time.Sleep(50 * time.Millisecond)
+	source.set(jellyfin, renamed)
+	select {
+	case response := <-responses:
+		t.Fatalf("expected the request to keep waiting, got status %d", response.Code)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// A version bump that changes the list wakes it up
+	moved := jellyfin
+	moved.Name = "Jellyfin Media"
+	source.set(moved, renamed)
+
+	select {
+	case response := <-responses:
+		if response.Code != http.StatusOK || response.Header().Get("ETag") == etag {
+			t.Fatalf("expected the changed list, got status %d", response.Code)
+		}
+		apps := []m.ContainerInfo{}
+		if err := json.Unmarshal(response.Body.Bytes(), &apps); err != nil || len(apps) != 1 || apps[0].Name != "Jellyfin Media" {
+			t.Errorf("expected the renamed application, got %s", response.Body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the long poll to return")
+	}
+}
+
+func TestGetApplicationsWaitTimesOut(t *testing.T) {
+	withApplications(t, jellyfin)
+	etag := getApplications("/api/v1/applications", "").Header().Get("ETag")
+
+	started := time.Now()
+	response := getApplications("/api/v1/applications?wait=100ms", etag)
+	if response.Code != http.StatusNotModified {
+		t.Errorf("expected status 304, got %d", response.Code)
+	}
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("expected the request to wait, it returned after %s", elapsed)
+	}
+}
+
+func TestGetApplicationsInvalidParams(t *testing.T) {
+	withApplications(t, jellyfin)
+
+	tests := []struct {
+		name   string
+		target string
+		detail string
+	}{
+		{name: "wait", target: "/api/v1/applications?wait=soon", detail: `invalid wait "soon"`},
+		{name: "negative wait", target: "/api/v1/applications?wait=-1s", detail: `invalid wait "-1s"`},
+		{name: "limit", target: "/api/v1/applications?limit=ten", detail: `invalid limit "ten"`},
+		{name: "offset", target: "/api/v1/applications?offset=-", detail: `invalid offset "-"`},
+		{name: "negative limit", target: "/api/v1/applications?limit=-1", detail: "invalid limit -1, must not be negative"},
+		{name: "negative offset", target: "/api/v1/applications?offset=-1", detail: "invalid offset -1, must not be negative"},
+		{name: "sort", target: "/api/v1/applications?sort=url", detail: `invalid sort "url", expected one of name, group, weight or added`},
+		{name: "source", target: "/api/v1/applications?source=Static", detail: `invalid source "Static", expected a source name like static or sidecar`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := getApplications(test.target, "")
+			if response.Code != http.StatusBadRequest || response.Header().Get("Content-Type") != "application/problem+json" {
+				t.Fatalf("expected a 400 problem, got status %d and %q", response.Code, response.Header().Get("Content-Type"))
+			}
+
+			problem := Problem{}
+			if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil || problem.Detail != test.detail {
+				t.Errorf("expected detail %q, got %s", test.detail, response.Body)
+			}
+		})
+	}
+}
+
+func TestParseWait(t *testing.T) {
+	tests := []struct {
+		param    string
+		expected time.Duration
+	}{
+		{param: "", expected: 0},
+		{param: "30s", expected: 30 * time.Second},
+		{param: "1h", expected: maxLongPollWait},
+	}
+
+	for _, test := range tests {
+		t.Run(test.param, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/applications?wait="+test.param, nil)
+			if wait, err := parseWait(request); err != nil || wait != test.expected {
+				t.Errorf("expected %s, got %s and %v", test.expected, wait, err)
+			}
+		})
+	}
+}
+
+func TestGetApplicationsForHost(t *testing.T) {
+	withApplications(t, jellyfin, gitea)
+
+	previous := c.Config.Dashboards
+	c.Config.Dashboards = []m.Dashboard{{Name: "media", Hosts: []string{"*.media.example.com"}, Groups: []string{"Media"}}}
+	t.Cleanup(func() { c.Config.Dashboards = previous })
+
+	previousProxies := network.TrustedProxies
+	network.TrustedProxies, _ = network.ParsePrefixes([]string{"10.0.0.1"})
+	t.Cleanup(func() { network.TrustedProxies = previousProxies })
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		host          string
+		forwardedHost string
+		expected      string
+	}{
+		{name: "dashboard host", remoteAddr: "203.0.113.7:51234", host: "tv.media.example.com", expected: "1"},
+		{name: "dashboard host with a port", remoteAddr: "203.0.113.7:51234", host: "tv.media.example.com:8080", expected: "1"},
+		{name: "other host falls back to every application", remoteAddr: "203.0.113.7:51234", host: "dash.example.com", expected: "2"},
+		{name: "forwarded by a trusted proxy", remoteAddr: "10.0.0.1:51234", host: "homedash:8080", forwardedHost: "tv.media.example.com", expected: "1"},
+		{name: "forwarded by anyone else", remoteAddr: "203.0.113.7:51234", host: "dash.example.com", forwardedHost: "tv.media.example.com", expected: "2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+			request.RemoteAddr = test.remoteAddr
+			request.Host = test.host
+			if test.forwardedHost != "" {
+				request.Header.Set("X-Forwarded-Host", test.forwardedHost)
+			}
+			response := httptest.NewRecorder()
+			(&V1{}).GetApplications(response, request)
+
+			if total := response.Header().Get("X-Total-Count"); response.Code != http.StatusOK || total != test.expected {
+				t.Errorf("expected %s applications, got status %d and %s", test.expected, response.Code, total)
+			}
+		})
+	}
+}
@@ -16,6 +16,6 @@ import (
 	r "github.com/mvdkleijn/homedash/internal/repositories"
 )
 
-func GetAppList() map[string][]m.ContainerInfo {
-	return r.GetAppList()
+func GetGroups() []m.Group {
+	return r.GetGroups()
 }
@@ -0,0 +1,81 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// GetDashboards returns all configured dashboards.
+func GetDashboards() []m.Dashboard {
+	return config.Config.Dashboards
+}
+
+// GetDashboard returns the dashboard with the given name.
+func GetDashboard(name string) (m.Dashboard, bool) {
+	for _, dashboard := range config.Config.Dashboards {
+		if strings.EqualFold(dashboard.Name, name) {
+			return dashboard, true
+		}
+	}
+
+	return m.Dashboard{}, false
+}
+
+// GetDashboardForHost returns the dashboard profile for the host the visitor
+// requested, if any.
+func GetDashboardForHost(visitor *Visitor) (m.Dashboard, bool) {
+	for _, dashboard := range config.Config.Dashboards {
+		if slices.ContainsFunc(dashboard.Hosts, visitor.hostMatches) {
+			return dashboard, true
+		}
+	}
+
+	return m.Dashboard{}, false
+}
+
+// dashboardMatches reports whether the container passes the filters of the
+// dashboard. A container must match one of the values of every filter. Apps
+// are matched by id or name.
+func dashboardMatches(dashboard *m.Dashboard, container m.ContainerInfo) bool {
+	equalFold := func(value string) func(string) bool {
+		return func(other string) bool { return strings.EqualFold(value, other) }
+	}
+
+	if len(dashboard.Apps) > 0 && !slices.ContainsFunc(dashboard.Apps, func(app string) bool {
+		return app == container.ID || strings.EqualFold(app, container.Name)
+	}) {
+		return false
+	}
+
+	if len(dashboard.Groups) > 0 && !slices.ContainsFunc(dashboard.Groups, equalFold(container.Group)) {
+		return false
+	}
+
+	if len(dashboard.Tags) > 0 && !slices.ContainsFunc(container.Tags, func(tag string) bool {
+		return slices.ContainsFunc(dashboard.Tags, equalFold(tag))
+	}) {
+		return false
+	}
+
+	if len(dashboard.Sidecars) > 0 && !slices.ContainsFunc(container.Sources, func(source string) bool {
+		return slices.Contains(dashboard.Sidecars, source)
+	}) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,131 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"net/http"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// withDashboards configures the dashboards for the duration of a test.
+func withDashboards(t *testing.T, dashboards ...m.Dashboard) {
+	previous := config.Config.Dashboards
+	config.Config.Dashboards = dashboards
+	t.Cleanup(func() { config.Config.Dashboards = previous })
+}
+
+func TestGetDashboardForHost(t *testing.T) {
+	withDashboards(t,
+		m.Dashboard{Name: "media", Hosts: []string{"media.example.com", "tv.home.arpa"}},
+		m.Dashboard{Name: "lan", Hosts: []string{"*.home.arpa"}},
+		m.Dashboard{Name: "all"},
+	)
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{host: "media.example.com", expected: "media"},
+		{host: "MEDIA.example.com", expected: "media"},
+		{host: "media.example.com:8080", expected: "media"},
+		{host: "tv.home.arpa", expected: "media"},
+		{host: "nas.home.arpa", expected: "lan"},
+		{host: "nas.home.arpa:443", expected: "lan"},
+		{host: "[fd00::1]:8080", expected: ""},
+		{host: "home.arpa", expected: ""},
+		{host: "evilhome.arpa", expected: ""},
+		{host: "www.media.example.com", expected: ""},
+		{host: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			visitor := &Visitor{Addr: netip.MustParseAddr("192.168.1.20"), Host: test.host, Header: http.Header{}}
+			dashboard, found := GetDashboardForHost(visitor)
+
+			// Without a matching host, the caller falls back to the full list
+			if found != (test.expected != "") || dashboard.Name != test.expected {
+				t.Errorf("expected dashboard %q, got %q (found %v)", test.expected, dashboard.Name, found)
+			}
+		})
+	}
+}
+
+func TestDashboardMatches(t *testing.T) {
+	jellyfin := m.ContainerInfo{ID: "jf", Name: "Jellyfin", Group: "Media", Tags: []string{"Video", "public"}, Sources: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}}
+
+	tests := []struct {
+		name      string
+		dashboard m.Dashboard
+		expected  bool
+	}{
+		{name: "without filters", dashboard: m.Dashboard{}, expected: true},
+		{name: "app by id", dashboard: m.Dashboard{Apps: []string{"gitea", "jf"}}, expected: true},
+		{name: "app id is case sensitive", dashboard: m.Dashboard{Apps: []string{"JF"}}, expected: false},
+		{name: "app by name", dashboard: m.Dashboard{Apps: []string{"jellyfin"}}, expected: true},
+		{name: "other app", dashboard: m.Dashboard{Apps: []string{"Gitea"}}, expected: false},
+		{name: "group", dashboard: m.Dashboard{Groups: []string{"Code", "media"}}, expected: true},
+		{name: "other group", dashboard: m.Dashboard{Groups: []string{"Code"}}, expected: false},
+		{name: "tag", dashboard: m.Dashboard{Tags: []string{"video"}}, expected: true},
+		{name: "other tag", dashboard: m.Dashboard{Tags: []string{"audio"}}, expected: false},
+		{name: "sidecar", dashboard: m.Dashboard{Sidecars: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}}, expected: true},
+		{name: "other sidecar", dashboard: m.Dashboard{Sidecars: []string{"0b9e3c8a-5f3e-4a2b-9c1d-2e4f6a8b0c1d"}}, expected: false},
+		{name: "every filter", dashboard: m.Dashboard{Apps: []string{"jf"}, Groups: []string{"Media"}, Tags: []string{"PUBLIC"}, Sidecars: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}}, expected: true},
+		{name: "one filter fails", dashboard: m.Dashboard{Groups: []string{"Media"}, Tags: []string{"audio"}}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := dashboardMatches(&test.dashboard, jellyfin); matches != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, matches)
+			}
+		})
+	}
+}
+
+func TestAppQueryDashboard(t *testing.T) {
+	containers := []m.ContainerInfo{
+		{Name: "Jellyfin", Group: "Media", Weight: 0},
+		{Name: "Gitea", Group: "Code", Weight: 1},
+		{Name: "Audiobookshelf", Group: "Media", Weight: 1},
+	}
+	dashboard := &m.Dashboard{Groups: []string{"media"}, Layout: m.DashboardLayout{Sort: SortByWeight}}
+
+	tests := []struct {
+		name     string
+		query    AppQuery
+		expected []string
+	}{
+		{name: "sorted by the layout", query: AppQuery{Dashboard: dashboard}, expected: []string{"Jellyfin", "Audiobookshelf"}},
+		{name: "sort parameter wins", query: AppQuery{Dashboard: dashboard, Sort: SortByName}, expected: []string{"Audiobookshelf", "Jellyfin"}},
+		{name: "other filters apply too", query: AppQuery{Dashboard: dashboard, Search: "jelly"}, expected: []string{"Jellyfin"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apps, total := test.query.Apply(containers)
+
+			names := []string{}
+			for _, app := range apps {
+				names = append(names, app.Name)
+			}
+			if !reflect.DeepEqual(names, test.expected) || total != len(test.expected) {
+				t.Errorf("expected %v, got %v of %d", test.expected, names, total)
+			}
+		})
+	}
+}
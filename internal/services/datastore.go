@@ -12,15 +12,16 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mvdkleijn/homedash/internal/config"
 	m "github.com/mvdkleijn/homedash/internal/models"
-	r "github.com/mvdkleijn/homedash/internal/repositories"
-
-	"golang.org/x/exp/maps"
 )
 
 type ContainerUpdate struct {
@@ -28,87 +29,206 @@ type ContainerUpdate struct {
 	Containers []m.ContainerInfo `json:"containers"`
 }
 
+// DataStore combines the applications of all sources and keeps track of
+// changes to them.
 type DataStore struct {
-	mu          sync.Mutex
-	LastUpdated map[string]time.Time
-	Containers  map[string][]m.ContainerInfo
+	mu        sync.Mutex
+	sources   []AppSource
+	FirstSeen map[string]time.Time
+
+	// rejected holds the invalid applications that were logged, so each is
+	// only logged once.
+	rejected map[string]bool
+
+	// version is bumped on every change to the stored data. Waiters block on
+	// changed, which is closed and replaced whenever the version is bumped.
+	version uint64
+	changed chan struct{}
 }
 
-func (ds *DataStore) CleanupOutdatedEntries(maxAgeInMinutes int) {
+func NewDataStore() *DataStore {
+	return &DataStore{
+		FirstSeen: map[string]time.Time{},
+	}
+}
+
+// AddSource adds the applications of the source and watches it for changes.
+func (ds *DataStore) AddSource(ctx context.Context, source AppSource) error {
+	if err := source.Watch(ctx, ds.Touch); err != nil {
+		return fmt.Errorf("failed to watch source %s: %w", source.Name(), err)
+	}
+
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 
-	now := time.Now()
-	uuids := maps.Keys(ds.Containers)
-	config.Logger.Debug().Msg("cleaning up outdated entries")
-	for _, uuid := range uuids {
-		// Remove data if no updates in X minutes or more
-		if now.Sub(ds.LastUpdated[uuid]) >= time.Duration(maxAgeInMinutes)*time.Minute {
-			config.Logger.Debug().Str("uuid", uuid).Msg("removing entries for sidecar")
-			delete(ds.Containers, uuid)
-			delete(ds.LastUpdated, uuid)
+	ds.sources = append(ds.sources, source)
+	ds.bumpVersion()
+
+	config.Logger.Debug().Str("source", source.Name()).Msg("added application source")
+
+	return nil
+}
+
+// SourcesHealth returns the health of every source.
+func (ds *DataStore) SourcesHealth() []SourceHealth {
+	health := []SourceHealth{}
+
+	for _, source := range ds.getSources() {
+		sourceHealth := SourceHealth{Name: source.Name(), Healthy: true}
+		if err := source.Health(); err != nil {
+			sourceHealth.Healthy = false
+			sourceHealth.Error = err.Error()
 		}
+		health = append(health, sourceHealth)
 	}
+
+	return health
 }
 
-func (ds *DataStore) GetLastUpdated(uuid string) (time.Time, bool) {
+func (ds *DataStore) getSources() []AppSource {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 
-	time, exists := ds.LastUpdated[uuid]
+	return slices.Clone(ds.sources)
+}
+
+// Version returns the current version of the stored data.
+func (ds *DataStore) Version() uint64 {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
 
-	return time, exists
+	return ds.version
 }
 
-func (ds *DataStore) GetContainerList() []m.ContainerInfo {
+// Touch bumps the version for changes made outside of the DataStore, like
+// reloaded static applications.
+func (ds *DataStore) Touch() {
 	ds.mu.Lock()
 	defer ds.mu.Unlock()
 
-	containerInfoList := []m.ContainerInfo{}
+	ds.bumpVersion()
+}
 
-	for _, containerList := range ds.Containers {
-		containerInfoList = append(containerInfoList, containerList...)
+// WaitForChange blocks until the version differs from the given version or
+// the context is done. It returns the version at that moment.
+func (ds *DataStore) WaitForChange(ctx context.Context, version uint64) uint64 {
+	ds.mu.Lock()
+	if ds.version != version {
+		defer ds.mu.Unlock()
+		return ds.version
 	}
+	changed := ds.changedChannel()
+	ds.mu.Unlock()
 
-	for _, containerList := range r.GetAppList() {
-		containerInfoList = append(containerInfoList, containerList...)
+	select {
+	case <-changed:
+	case <-ctx.Done():
 	}
 
-	return ds.sortContainersByName(containerInfoList)
+	return ds.Version()
 }
 
-func (ds *DataStore) sortContainersByName(containers []m.ContainerInfo) []m.ContainerInfo {
-	sort.Slice(containers, func(i, j int) bool {
-		return containers[i].Name < containers[j].Name
-	})
+// changedChannel must be called with the lock held.
+func (ds *DataStore) changedChannel() chan struct{} {
+	if ds.changed == nil {
+		ds.changed = make(chan struct{})
+	}
 
-	return containers
+	return ds.changed
+}
+
+// bumpVersion must be called with the lock held.
+func (ds *DataStore) bumpVersion() {
+	ds.version++
+	close(ds.changedChannel())
+	ds.changed = make(chan struct{})
 }
 
-func (ds *DataStore) GetSidecarList() []string {
+func (ds *DataStore) GetContainerList() []m.ContainerInfo {
+	containerInfoList := []m.ContainerInfo{}
+
+	// Sources are listed without the lock held, as they may call Touch
+	for _, source := range ds.getSources() {
+		containerInfoList = append(containerInfoList, source.List()...)
+	}
+
 	ds.mu.Lock()
-	defer ds.mu.Unlock()
+	containerInfoList = ds.dropInvalid(containerInfoList)
+	ds.mu.Unlock()
 
-	return maps.Keys(ds.Containers)
-}
+	containerInfoList = mergeContainers(containerInfoList, config.Config.Merge.Policy, config.Config.Merge.Key)
 
-func (ds *DataStore) AddEntries(uuid string, containers []m.ContainerInfo) {
 	ds.mu.Lock()
-	defer ds.mu.Unlock()
+	ds.updateFirstSeen(containerInfoList)
+	ds.mu.Unlock()
 
-	ds.LastUpdated[uuid] = time.Now()
-	ds.Containers[uuid] = containers
+	return ds.sortContainersByName(containerInfoList)
 }
 
-func (ds *DataStore) ReplaceEntries(uuid string, containers []m.ContainerInfo) {
-	// TODO: Maybe check if entry already exists in future but not sure why we'd want to right now.
-	ds.AddEntries(uuid, containers)
+// QueryContainerList returns the subset of containers requested by the query
+// and the total number of matching containers before pagination.
+func (ds *DataStore) QueryContainerList(query AppQuery) ([]m.ContainerInfo, int) {
+	return query.Apply(ds.GetContainerList())
 }
 
-func (ds *DataStore) DeleteAllEntries(uuid string) {
-	ds.mu.Lock()
-	defer ds.mu.Unlock()
+// dropInvalid removes the applications the API would refuse, whichever source
+// they came from, so a device announcing a javascript: URL can't get it onto
+// the dashboard. Must be called with the lock held.
+func (ds *DataStore) dropInvalid(containers []m.ContainerInfo) []m.ContainerInfo {
+	valid := make([]m.ContainerInfo, 0, len(containers))
+	rejected := map[string]bool{}
+
+	for _, container := range containers {
+		invalid := ValidateContainer(container, "", config.Config.API.AllowedSchemes)
+		if len(invalid) == 0 {
+			valid = append(valid, container)
+			continue
+		}
+
+		key := strings.Join(container.Sources, ",") + "|" + container.Name + "|" + container.Url
+		if !ds.rejected[key] {
+			config.Logger.Warn().Strs("sources", container.Sources).Str("name", container.Name).
+				Str("field", invalid[0].Field).Str("reason", invalid[0].Reason).Msg("dropped invalid application")
+		}
+		rejected[key] = true
+	}
+
+	ds.rejected = rejected
+
+	return valid
+}
+
+// updateFirstSeen records when each application was first seen and forgets
+// applications that are no longer present. Must be called with the lock held.
+func (ds *DataStore) updateFirstSeen(containers []m.ContainerInfo) {
+	if ds.FirstSeen == nil {
+		ds.FirstSeen = map[string]time.Time{}
+	}
 
-	delete(ds.LastUpdated, uuid)
-	delete(ds.Containers, uuid)
+	now := time.Now()
+	present := make(map[string]bool, len(containers))
+
+	for i := range containers {
+		id := containers[i].ID
+		present[id] = true
+
+		if _, exists := ds.FirstSeen[id]; !exists {
+			ds.FirstSeen[id] = now
+		}
+		containers[i].Added = ds.FirstSeen[id]
+	}
+
+	for id := range ds.FirstSeen {
+		if !present[id] {
+			delete(ds.FirstSeen, id)
+		}
+	}
+}
+
+func (ds *DataStore) sortContainersByName(containers []m.ContainerInfo) []m.ContainerInfo {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+
+	return containers
 }
@@ -0,0 +1,60 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+func TestMain(tests *testing.M) {
+	logger := zerolog.Nop()
+	config.Logger = &logger
+	config.Config.API.AllowedSchemes = []string{"http", "https"}
+
+	os.Exit(tests.Run())
+}
+
+// fakeSource lists fixed applications.
+type fakeSource struct {
+	apps []m.ContainerInfo
+}
+
+func (f *fakeSource) Name() string                                     { return "fake" }
+func (f *fakeSource) List() []m.ContainerInfo                          { return f.apps }
+func (f *fakeSource) Watch(ctx context.Context, onChange func()) error { return nil }
+func (f *fakeSource) Health() error                                    { return nil }
+
+func TestDataStoreDropsInvalidApplications(t *testing.T) {
+	source := &fakeSource{apps: []m.ContainerInfo{
+		{Name: "Jellyfin", Url: "https://jellyfin.example.com", Sources: []string{"mdns:lan"}},
+		{Name: "Printer", Url: "javascript:alert(document.cookie)", Sources: []string{"mdns:lan"}},
+		{Name: "Router", Url: "https://router.example.com", ExternalUrl: "data:text/html,hi", Sources: []string{"mdns:lan"}},
+		{Name: "NAS", Url: "https://nas.example.com", Status: m.StatusStale, Sources: []string{"upstream:ams"}},
+	}}
+
+	ds := NewDataStore()
+	if err := ds.AddSource(context.Background(), source); err != nil {
+		t.Fatal(err)
+	}
+
+	apps := ds.GetContainerList()
+	if len(apps) != 2 || apps[0].Name != "Jellyfin" || apps[1].Name != "NAS" {
+		t.Errorf("expected only Jellyfin and NAS, got %v", apps)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+const (
+	StaticSource = "static"
+
+	MergeKeepAll      = "keep-all"
+	MergePreferStatic = "prefer-static"
+	MergePreferNewest = "prefer-newest"
+
+	MergeKeyUrl  = "url"
+	MergeKeyName = "name"
+)
+
+// AppID returns the explicit ID of an application or, when none was given,
+// one derived from its name and URL so it stays stable between updates.
+func AppID(app m.ContainerInfo) string {
+	if app.ID != "" {
+		return app.ID
+	}
+
+	sum := sha1.Sum([]byte(strings.ToLower(strings.TrimSpace(app.Name)) + "|" + normalizeUrl(app.Url)))
+
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// normalizeUrl makes URLs comparable by ignoring the scheme, the case of the
+// host and any trailing slash.
+func normalizeUrl(rawUrl string) string {
+	rawUrl = strings.TrimSpace(rawUrl)
+
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(strings.ToLower(rawUrl), "/")
+	}
+
+	normalized := strings.ToLower(u.Host) + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		normalized += "?" + u.RawQuery
+	}
+
+	return normalized
+}
+
+func mergeKey(app m.ContainerInfo, key string) string {
+	if key == MergeKeyName {
+		return strings.ToLower(strings.TrimSpace(app.Name))
+	}
+
+	return normalizeUrl(app.Url)
+}
+
+// mergeContainers combines duplicate entries according to the merge policy.
+// Every entry is expected to carry exactly one source. Merged entries report
+// all sources that contributed to them.
+func mergeContainers(containers []m.ContainerInfo, policy string, key string) []m.ContainerInfo {
+	for i := range containers {
+		containers[i].ID = AppID(containers[i])
+	}
+
+	if policy != MergePreferStatic && policy != MergePreferNewest {
+		return uniqueIDs(containers)
+	}
+
+	order := []string{}
+	groups := make(map[string][]m.ContainerInfo)
+
+	for _, container := range containers {
+		k := mergeKey(container, key)
+		if _, exists := groups[k]; !exists {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], container)
+	}
+
+	merged := make([]m.ContainerInfo, 0, len(order))
+
+	for _, k := range order {
+		group := groups[k]
+		winner := group[0]
+		sources := []string{}
+
+		for _, candidate := range group {
+			sources = append(sources, candidate.Sources...)
+			if preferCandidate(candidate, winner, policy) {
+				winner = candidate
+			}
+		}
+
+		slices.Sort(sources)
+		winner.Sources = slices.Compact(sources)
+		merged = append(merged, winner)
+	}
+
+	return merged
+}
+
+// uniqueIDs gives entries that are kept apart but share an ID, like the same
+// application reported by two sources, unique IDs. The first entry, the
+// static one or the one from the earliest source, keeps its ID so it doesn't
+// change when a duplicate shows up. The others get a suffix for their source.
+func uniqueIDs(containers []m.ContainerInfo) []m.ContainerInfo {
+	taken := map[string]bool{}
+	for i, container := range containers {
+		if !taken[container.ID] {
+			taken[container.ID] = true
+			continue
+		}
+
+		sum := sha1.Sum([]byte(strings.Join(container.Sources, ",")))
+		base := container.ID + "-" + hex.EncodeToString(sum[:])[:6]
+
+		// A source can report the same application twice
+		id := base
+		for n := 2; taken[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		taken[id] = true
+		containers[i].ID = id
+	}
+
+	return containers
+}
+
+func preferCandidate(candidate m.ContainerInfo, current m.ContainerInfo, policy string) bool {
+	candidateStatic := slices.Contains(candidate.Sources, StaticSource)
+	currentStatic := slices.Contains(current.Sources, StaticSource)
+
+	if policy == MergePreferStatic && candidateStatic != currentStatic {
+		return candidateStatic
+	}
+
+	if !candidate.Updated.Equal(current.Updated) {
+		return candidate.Updated.After(current.Updated)
+	}
+
+	// Break ties the same way every time, static entries have no update time
+	if sourcePriority(candidate) != sourcePriority(current) {
+		return sourcePriority(candidate) < sourcePriority(current)
+	}
+	if candidate.Name != current.Name {
+		return candidate.Name < current.Name
+	}
+
+	return strings.Join(candidate.Sources, ",") < strings.Join(current.Sources, ",")
+}
+
+// sourcePriority ranks static applications before discovered ones, and those
+// before applications reported by sidecars.
+func sourcePriority(container m.ContainerInfo) int {
+	switch {
+	case slices.Contains(container.Sources, StaticSource):
+		return 0
+	case slices.ContainsFunc(container.Sources, func(id string) bool { return SourceName(id) == SourceTypeSidecar }):
+		return 2
+	default:
+		return 1
+	}
+}
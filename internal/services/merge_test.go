@@ -0,0 +1,96 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+func TestMergeKeepAllGivesDuplicatesUniqueIDs(t *testing.T) {
+	containers := mergeContainers([]m.ContainerInfo{
+		{Name: "Jellyfin", Url: "https://jf.example.com", Sources: []string{StaticSource}},
+		{Name: "Jellyfin", Url: "https://jf.example.com/", Sources: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}},
+		{Name: "Gitea", Url: "https://git.example.com", Sources: []string{StaticSource}},
+	}, MergeKeepAll, MergeKeyUrl)
+
+	seen := map[string]bool{}
+	for _, container := range containers {
+		if seen[container.ID] {
+			t.Fatalf("duplicate id %s", container.ID)
+		}
+		seen[container.ID] = true
+	}
+
+	gitea := AppID(m.ContainerInfo{Name: "Gitea", Url: "https://git.example.com"})
+	if containers[2].ID != gitea {
+		t.Errorf("id of an application without duplicates changed to %s, expected %s", containers[2].ID, gitea)
+	}
+}
+
+func TestMergeKeepAllKeepsTheFirstID(t *testing.T) {
+	static := m.ContainerInfo{Name: "Jellyfin", Url: "https://jf.example.com", Sources: []string{StaticSource}}
+	discovered := m.ContainerInfo{Name: "Jellyfin", Url: "https://jf.example.com", Sources: []string{"traefik:default"}}
+	sidecar := m.ContainerInfo{Name: "Jellyfin", Url: "https://jf.example.com", Sources: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}}
+
+	before := mergeContainers([]m.ContainerInfo{static}, MergeKeepAll, MergeKeyUrl)
+	after := mergeContainers([]m.ContainerInfo{static, discovered, sidecar, sidecar}, MergeKeepAll, MergeKeyUrl)
+
+	if after[0].ID != before[0].ID {
+		t.Errorf("expected the static entry to keep id %s, got %s", before[0].ID, after[0].ID)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "discovered", expected: before[0].ID + "-"},
+		{name: "sidecar", expected: before[0].ID + "-"},
+		{name: "sidecar again", expected: after[2].ID + "-2"},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id := after[i+1].ID
+			if !strings.HasPrefix(id, test.expected) || id == after[i].ID {
+				t.Errorf("expected an id starting with %s, got %s", test.expected, id)
+			}
+		})
+	}
+
+	// Without the static entry, the discovered one comes first and keeps the id
+	withoutStatic := mergeContainers([]m.ContainerInfo{discovered, sidecar}, MergeKeepAll, MergeKeyUrl)
+	if withoutStatic[0].ID != before[0].ID {
+		t.Errorf("expected the first source to keep id %s, got %s", before[0].ID, withoutStatic[0].ID)
+	}
+}
+
+func TestMergePreferNewestBreaksTiesBySource(t *testing.T) {
+	updated := time.Now()
+	sidecar := m.ContainerInfo{Name: "Jellyfin", Url: "https://jf.example.com", Comment: "sidecar", Updated: updated, Sources: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}}
+	discovered := m.ContainerInfo{Name: "Jellyfin", Url: "https://jf.example.com", Comment: "traefik", Updated: updated, Sources: []string{"traefik:default"}}
+
+	for _, order := range [][]m.ContainerInfo{{sidecar, discovered}, {discovered, sidecar}} {
+		merged := mergeContainers(order, MergePreferNewest, MergeKeyUrl)
+		if len(merged) != 1 {
+			t.Fatalf("expected 1 application, got %d", len(merged))
+		}
+		if merged[0].Comment != "traefik" {
+			t.Errorf("expected the discovered entry to win the tie, got %s", merged[0].Comment)
+		}
+		if len(merged[0].Sources) != 2 {
+			t.Errorf("expected both sources, got %v", merged[0].Sources)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+const (
+	SortByName   = "name"
+	SortByGroup  = "group"
+	SortByWeight = "weight"
+	SortByAdded  = "added"
+
+	SourceTypeSidecar = "sidecar"
+)
+
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// AppQuery describes which subset of the application list a client wants.
+// Empty fields do not filter. A Limit of 0 means no limit.
+type AppQuery struct {
+	Group   string
+	Tag     string
+	Sidecar string
+	Source  string
+	Search  string
+	Sort    string
+	Limit   int
+	Offset  int
+
+	// Visitor limits the result to applications the visitor may see. Without
+	// a visitor, visibility rules are not applied.
+	Visitor *Visitor
+
+	// Dashboard limits the result to the applications on that dashboard.
+	Dashboard *m.Dashboard
+}
+
+func (q AppQuery) Validate() error {
+	switch q.Sort {
+	case "", SortByName, SortByGroup, SortByWeight, SortByAdded:
+	default:
+		return fmt.Errorf("invalid sort %q, expected one of name, group, weight or added", q.Sort)
+	}
+
+	if q.Source != "" && !sourceNamePattern.MatchString(q.Source) {
+		return fmt.Errorf("invalid source %q, expected a source name like static or sidecar", q.Source)
+	}
+
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid limit %d, must not be negative", q.Limit)
+	}
+
+	if q.Offset < 0 {
+		return fmt.Errorf("invalid offset %d, must not be negative", q.Offset)
+	}
+
+	return nil
+}
+
+// Apply filters, sorts and paginates the containers. It returns the requested
+// page and the total number of containers that matched the filters.
+func (q AppQuery) Apply(containers []m.ContainerInfo) ([]m.ContainerInfo, int) {
+	filtered := []m.ContainerInfo{}
+
+	if q.Visitor != nil {
+		containers = FilterVisible(containers, GetGroups(), q.Visitor)
+	}
+
+	for _, container := range containers {
+		if q.matches(container) {
+			filtered = append(filtered, container)
+		}
+	}
+
+	sortBy := q.Sort
+	if sortBy == "" && q.Dashboard != nil {
+		sortBy = q.Dashboard.Layout.Sort
+	}
+	sortContainers(filtered, sortBy)
+
+	total := len(filtered)
+	start := min(q.Offset, total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+
+	return filtered[start:end], total
+}
+
+func (q AppQuery) matches(container m.ContainerInfo) bool {
+	if q.Dashboard != nil && !dashboardMatches(q.Dashboard, container) {
+		return false
+	}
+
+	if q.Group != "" && !strings.EqualFold(container.Group, q.Group) {
+		return false
+	}
+
+	if q.Tag != "" && !slices.ContainsFunc(container.Tags, func(tag string) bool {
+		return strings.EqualFold(tag, q.Tag)
+	}) {
+		return false
+	}
+
+	if q.Sidecar != "" && !slices.Contains(container.Sources, q.Sidecar) {
+		return false
+	}
+
+	if q.Source != "" && !slices.ContainsFunc(container.Sources, func(id string) bool {
+		return SourceName(id) == q.Source
+	}) {
+		return false
+	}
+
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(container.Name), search) &&
+			!strings.Contains(strings.ToLower(container.Comment), search) &&
+			!strings.Contains(strings.ToLower(container.Url), search) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortContainers sorts by the requested field, always falling back to the
+// name so the order is predictable.
+func sortContainers(containers []m.ContainerInfo, sortBy string) {
+	sort.SliceStable(containers, func(i, j int) bool {
+		a, b := containers[i], containers[j]
+
+		switch sortBy {
+		case SortByGroup:
+			if a.Group != b.Group {
+				// Ungrouped applications go last
+				if a.Group == "" || b.Group == "" {
+					return b.Group == ""
+				}
+				return a.Group < b.Group
+			}
+		case SortByWeight:
+			if a.Weight != b.Weight {
+				return a.Weight < b.Weight
+			}
+		case SortByAdded:
+			if !a.Added.Equal(b.Added) {
+				return a.Added.After(b.Added)
+			}
+		}
+
+		return a.Name < b.Name
+	})
+}
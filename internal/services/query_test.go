@@ -0,0 +1,104 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+func TestAppQueryValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    AppQuery
+		expected string
+	}{
+		{name: "empty", query: AppQuery{}},
+		{name: "everything", query: AppQuery{Group: "Media", Tag: "video", Source: "upstream", Search: "jelly", Sort: SortByAdded, Limit: 10, Offset: 20}},
+		{name: "sort", query: AppQuery{Sort: "url"}, expected: `invalid sort "url", expected one of name, group, weight or added`},
+		{name: "sort case", query: AppQuery{Sort: "Name"}, expected: `invalid sort "Name", expected one of name, group, weight or added`},
+		{name: "source", query: AppQuery{Source: "traefik:default"}, expected: `invalid source "traefik:default", expected a source name like static or sidecar`},
+		{name: "limit", query: AppQuery{Limit: -1}, expected: "invalid limit -1, must not be negative"},
+		{name: "offset", query: AppQuery{Offset: -5}, expected: "invalid offset -5, must not be negative"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.query.Validate()
+			if test.expected == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("expected error %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestAppQueryApply(t *testing.T) {
+	now := time.Now()
+	containers := []m.ContainerInfo{
+		{Name: "Jellyfin", Url: "https://jellyfin.example.com", Group: "Media", Tags: []string{"Video"}, Weight: 2, Added: now.Add(-time.Hour), Sources: []string{"traefik:default"}},
+		{Name: "Gitea", Url: "https://git.example.com", Comment: "Source code", Group: "Code", Weight: 1, Added: now, Sources: []string{StaticSource}},
+		{Name: "Audiobookshelf", Url: "https://books.example.com", Group: "media", Tags: []string{"audio"}, Weight: 2, Added: now.Add(-2 * time.Hour), Sources: []string{"14a107d2-db4b-4419-a7fe-f1499ad02ee7"}},
+		{Name: "Router", Url: "https://router.lan", Weight: 0, Added: now.Add(-3 * time.Hour), Sources: []string{"mdns:lan", StaticSource}},
+	}
+
+	tests := []struct {
+		name     string
+		query    AppQuery
+		expected []string
+		total    int
+	}{
+		{name: "everything by name", query: AppQuery{}, expected: []string{"Audiobookshelf", "Gitea", "Jellyfin", "Router"}, total: 4},
+		{name: "group ignores case", query: AppQuery{Group: "MEDIA"}, expected: []string{"Audiobookshelf", "Jellyfin"}, total: 2},
+		{name: "tag ignores case", query: AppQuery{Tag: "video"}, expected: []string{"Jellyfin"}, total: 1},
+		{name: "sidecar", query: AppQuery{Sidecar: "14a107d2-db4b-4419-a7fe-f1499ad02ee7"}, expected: []string{"Audiobookshelf"}, total: 1},
+		{name: "static source", query: AppQuery{Source: StaticSource}, expected: []string{"Gitea", "Router"}, total: 2},
+		{name: "sidecar source", query: AppQuery{Source: SourceTypeSidecar}, expected: []string{"Audiobookshelf"}, total: 1},
+		{name: "discovery source", query: AppQuery{Source: "mdns"}, expected: []string{"Router"}, total: 1},
+		{name: "search in the name", query: AppQuery{Search: "FIN"}, expected: []string{"Jellyfin"}, total: 1},
+		{name: "search in the comment", query: AppQuery{Search: "source"}, expected: []string{"Gitea"}, total: 1},
+		{name: "search in the url", query: AppQuery{Search: ".lan"}, expected: []string{"Router"}, total: 1},
+		{name: "no match", query: AppQuery{Group: "Media", Tag: "audio", Search: "jelly"}, expected: []string{}, total: 0},
+		{name: "sort by group", query: AppQuery{Sort: SortByGroup}, expected: []string{"Gitea", "Jellyfin", "Audiobookshelf", "Router"}, total: 4},
+		{name: "sort by weight", query: AppQuery{Sort: SortByWeight}, expected: []string{"Router", "Gitea", "Audiobookshelf", "Jellyfin"}, total: 4},
+		{name: "sort by added", query: AppQuery{Sort: SortByAdded}, expected: []string{"Gitea", "Jellyfin", "Audiobookshelf", "Router"}, total: 4},
+		{name: "limit", query: AppQuery{Limit: 2}, expected: []string{"Audiobookshelf", "Gitea"}, total: 4},
+		{name: "offset", query: AppQuery{Offset: 3}, expected: []string{"Router"}, total: 4},
+		{name: "limit and offset", query: AppQuery{Limit: 2, Offset: 1}, expected: []string{"Gitea", "Jellyfin"}, total: 4},
+		{name: "limit past the end", query: AppQuery{Limit: 10, Offset: 3}, expected: []string{"Router"}, total: 4},
+		{name: "offset past the end", query: AppQuery{Offset: 10}, expected: []string{}, total: 4},
+		{name: "filtered page", query: AppQuery{Group: "media", Sort: SortByAdded, Limit: 1, Offset: 1}, expected: []string{"Audiobookshelf"}, total: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apps, total := test.query.Apply(slices.Clone(containers))
+
+			names := []string{}
+			for _, app := range apps {
+				names = append(names, app.Name)
+			}
+			if !reflect.DeepEqual(names, test.expected) || total != test.total {
+				t.Errorf("expected %v of %d, got %v of %d", test.expected, test.total, names, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+
+	"golang.org/x/exp/maps"
+)
+
+// SidecarStore keeps the applications reported by sidecars, by sidecar uuid.
+type SidecarStore struct {
+	mu          sync.Mutex
+	LastUpdated map[string]time.Time
+	Containers  map[string][]m.ContainerInfo
+
+	onChange func()
+}
+
+func NewSidecarStore() *SidecarStore {
+	return &SidecarStore{
+		LastUpdated: map[string]time.Time{},
+		Containers:  map[string][]m.ContainerInfo{},
+	}
+}
+
+func (ss *SidecarStore) Name() string {
+	return SourceTypeSidecar
+}
+
+// List returns the applications of all sidecars, with the sidecar as source.
+func (ss *SidecarStore) List() []m.ContainerInfo {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	containerInfoList := []m.ContainerInfo{}
+
+	for uuid, containerList := range ss.Containers {
+		for _, container := range containerList {
+			container.Sources = []string{uuid}
+			container.Updated = ss.LastUpdated[uuid]
+			containerInfoList = append(containerInfoList, container)
+		}
+	}
+
+	return containerInfoList
+}
+
+// Watch remembers onChange, which is called by the methods that change the
+// stored applications.
+func (ss *SidecarStore) Watch(ctx context.Context, onChange func()) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.onChange = onChange
+
+	return nil
+}
+
+// Health always returns nil, sidecars that stop reporting are cleaned up.
+func (ss *SidecarStore) Health() error {
+	return nil
+}
+
+// notify calls onChange. It must be called without the lock held, as onChange
+// usually reads the store.
+func (ss *SidecarStore) notify() {
+	ss.mu.Lock()
+	onChange := ss.onChange
+	ss.mu.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+}
+
+func (ss *SidecarStore) CleanupOutdatedEntries(maxAgeInMinutes int) {
+	ss.mu.Lock()
+
+	changed := false
+	now := time.Now()
+	uuids := maps.Keys(ss.Containers)
+	config.Logger.Debug().Msg("cleaning up outdated entries")
+	for _, uuid := range uuids {
+		// Remove data if no updates in X minutes or more
+		if now.Sub(ss.LastUpdated[uuid]) >= time.Duration(maxAgeInMinutes)*time.Minute {
+			config.Logger.Debug().Str("uuid", uuid).Msg("removing entries for sidecar")
+			delete(ss.Containers, uuid)
+			delete(ss.LastUpdated, uuid)
+			changed = true
+		}
+	}
+
+	ss.mu.Unlock()
+
+	if changed {
+		ss.notify()
+	}
+}
+
+func (ss *SidecarStore) GetLastUpdated(uuid string) (time.Time, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	time, exists := ss.LastUpdated[uuid]
+
+	return time, exists
+}
+
+func (ss *SidecarStore) GetSidecarList() []string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return maps.Keys(ss.Containers)
+}
+
+func (ss *SidecarStore) AddEntries(uuid string, containers []m.ContainerInfo) {
+	ss.mu.Lock()
+
+	// Sidecars report regularly, so only a changed list is considered a change.
+	current, exists := ss.Containers[uuid]
+	changed := !exists || !reflect.DeepEqual(current, containers)
+
+	ss.LastUpdated[uuid] = time.Now()
+	ss.Containers[uuid] = containers
+
+	ss.mu.Unlock()
+
+	if changed {
+		ss.notify()
+	}
+}
+
+func (ss *SidecarStore) ReplaceEntries(uuid string, containers []m.ContainerInfo) {
+	// TODO: Maybe check if entry already exists in future but not sure why we'd want to right now.
+	ss.AddEntries(uuid, containers)
+}
+
+func (ss *SidecarStore) DeleteAllEntries(uuid string) {
+	ss.mu.Lock()
+
+	_, exists := ss.Containers[uuid]
+	delete(ss.LastUpdated, uuid)
+	delete(ss.Containers, uuid)
+
+	ss.mu.Unlock()
+
+	if exists {
+		ss.notify()
+	}
+}
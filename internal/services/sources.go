@@ -0,0 +1,52 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"context"
+	"strings"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// AppSource provides applications to the DataStore. Every application it lists
+// has Sources set to the ids it came from, like "static", a sidecar uuid or
+// "<name>:<instance>" for sources with more than one instance.
+type AppSource interface {
+	// Name identifies the kind of source, as used by the source filter.
+	Name() string
+	// List returns the applications currently provided by the source.
+	List() []m.ContainerInfo
+	// Watch calls onChange whenever the list changes, until ctx is done.
+	Watch(ctx context.Context, onChange func()) error
+	// Health returns why the source isn't working, or nil when it is.
+	Health() error
+}
+
+// SourceHealth is the health of a single source, as shown by the API.
+type SourceHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SourceName returns the name of the source an id from the Sources field of an
+// application belongs to.
+func SourceName(id string) string {
+	if uuidPattern.MatchString(id) {
+		return SourceTypeSidecar
+	}
+
+	name, _, _ := strings.Cut(id, ":")
+
+	return name
+}
@@ -0,0 +1,59 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"context"
+	"fmt"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+	r "github.com/mvdkleijn/homedash/internal/repositories"
+)
+
+// StaticApps is the source of the applications from the config file, the apps
+// directory and the admin API.
+type StaticApps struct{}
+
+func (sa *StaticApps) Name() string {
+	return StaticSource
+}
+
+func (sa *StaticApps) List() []m.ContainerInfo {
+	containers := r.GetStaticApps()
+	for i := range containers {
+		containers[i].Sources = []string{StaticSource}
+	}
+
+	return containers
+}
+
+// Watch watches the apps directory. Changes made through the admin API are
+// reported by the API itself.
+func (sa *StaticApps) Watch(ctx context.Context, onChange func()) error {
+	return r.AppsDir.Watch(ctx, onChange)
+}
+
+// Health reports files in the apps directory that failed to load.
+func (sa *StaticApps) Health() error {
+	broken := 0
+	for _, file := range r.AppsDir.Files() {
+		if file.Error != "" {
+			broken++
+		}
+	}
+
+	if broken > 0 {
+		return fmt.Errorf("%d files in the apps directory failed to load", broken)
+	}
+
+	return nil
+}
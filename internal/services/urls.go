@@ -0,0 +1,33 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// SelectUrls replaces the url of each application with its internal or
+// external url, depending on the network of the client. Applications without
+// an alternate url for that network keep their url.
+func SelectUrls(containers []m.ContainerInfo, internalClient bool) []m.ContainerInfo {
+	for i := range containers {
+		if internalClient && containers[i].InternalUrl != "" {
+			containers[i].Url = containers[i].InternalUrl
+		}
+
+		if !internalClient && containers[i].ExternalUrl != "" {
+			containers[i].Url = containers[i].ExternalUrl
+		}
+	}
+
+	return containers
+}
@@ -0,0 +1,189 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	"github.com/mvdkleijn/homedash/internal/network"
+)
+
+const (
+	maxIDLength      = 100
+	maxNameLength    = 100
+	maxUrlLength     = 2048
+	maxIconLength    = 100
+	maxCommentLength = 500
+	maxGroupLength   = 100
+	maxTagLength     = 50
+	maxTags          = 20
+
+	maxDescriptionLength   = 2000
+	maxMetadataEntries     = 20
+	maxMetadataKeyLength   = 50
+	maxMetadataValueLength = 200
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// FieldError describes why a single field of a request is invalid.
+type FieldError struct {
+	Field  string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ValidateContainerUpdate checks a sidecar payload and returns every invalid
+// field it finds, or nil when the payload is valid.
+func ValidateContainerUpdate(update m.ContainerUpdate, maxApps int, allowedSchemes []string) []FieldError {
+	errors := []FieldError{}
+
+	if update.Uuid == "" {
+		errors = append(errors, FieldError{"uuid", "is required"})
+	} else if !uuidPattern.MatchString(update.Uuid) {
+		errors = append(errors, FieldError{"uuid", "must be a UUID like 14a107d2-db4b-4419-a7fe-f1499ad02ee7"})
+	}
+
+	if maxApps > 0 && len(update.Containers) > maxApps {
+		errors = append(errors, FieldError{"containers", fmt.Sprintf("must not contain more than %d applications", maxApps)})
+	}
+
+	for i, container := range update.Containers {
+		errors = append(errors, ValidateContainer(container, fmt.Sprintf("containers[%d].", i), allowedSchemes)...)
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+
+	return errors
+}
+
+// ValidateContainer checks a single application. The prefix is prepended to
+// the names of invalid fields.
+func ValidateContainer(container m.ContainerInfo, prefix string, allowedSchemes []string) []FieldError {
+	errors := []FieldError{}
+
+	checkLength := func(field string, value string, max int) {
+		if utf8.RuneCountInString(value) > max {
+			errors = append(errors, FieldError{prefix + field, fmt.Sprintf("must not be longer than %d characters", max)})
+		}
+	}
+
+	checkLength("id", container.ID, maxIDLength)
+
+	if strings.TrimSpace(container.Name) == "" {
+		errors = append(errors, FieldError{prefix + "name", "is required"})
+	}
+	checkLength("name", container.Name, maxNameLength)
+
+	if reason := validateUrl(container.Url, allowedSchemes); reason != "" {
+		errors = append(errors, FieldError{prefix + "url", reason})
+	}
+
+	checkLength("icon", container.Icon, maxIconLength)
+	checkLength("comment", container.Comment, maxCommentLength)
+	checkLength("group", container.Group, maxGroupLength)
+
+	if len(container.Tags) > maxTags {
+		errors = append(errors, FieldError{prefix + "tags", fmt.Sprintf("must not contain more than %d tags", maxTags)})
+	}
+	for i, tag := range container.Tags {
+		checkLength(fmt.Sprintf("tags[%d]", i), tag, maxTagLength)
+	}
+
+	checkLength("description", container.Description, maxDescriptionLength)
+
+	if container.Target != "" && container.Target != m.TargetNewTab && container.Target != m.TargetSameTab {
+		errors = append(errors, FieldError{prefix + "target", fmt.Sprintf("must be %s or %s", m.TargetNewTab, m.TargetSameTab)})
+	}
+
+	switch container.Status {
+	case "", m.StatusUp, m.StatusDegraded, m.StatusDown, m.StatusStale:
+	default:
+		errors = append(errors, FieldError{prefix + "status", fmt.Sprintf("must be %s, %s, %s or %s", m.StatusUp, m.StatusDegraded, m.StatusDown, m.StatusStale)})
+	}
+
+	if container.InternalUrl != "" {
+		if reason := validateUrl(container.InternalUrl, allowedSchemes); reason != "" {
+			errors = append(errors, FieldError{prefix + "internalUrl", reason})
+		}
+	}
+
+	if container.ExternalUrl != "" {
+		if reason := validateUrl(container.ExternalUrl, allowedSchemes); reason != "" {
+			errors = append(errors, FieldError{prefix + "externalUrl", reason})
+		}
+	}
+
+	if len(container.Metadata) > maxMetadataEntries {
+		errors = append(errors, FieldError{prefix + "metadata", fmt.Sprintf("must not contain more than %d entries", maxMetadataEntries)})
+	}
+	for _, key := range slices.Sorted(maps.Keys(container.Metadata)) {
+		value := container.Metadata[key]
+		if utf8.RuneCountInString(key) > maxMetadataKeyLength {
+			errors = append(errors, FieldError{prefix + "metadata", fmt.Sprintf("keys must not be longer than %d characters", maxMetadataKeyLength)})
+		}
+		checkLength("metadata."+key, value, maxMetadataValueLength)
+	}
+
+	for i, rule := range container.Visibility {
+		if _, err := network.ParsePrefixes(rule.Networks); err != nil {
+			errors = append(errors, FieldError{fmt.Sprintf("%svisibility[%d].networks", prefix, i), "must be a list of CIDR ranges or addresses"})
+		}
+	}
+
+	return errors
+}
+
+// ValidateApp checks a static application the same way the API does and
+// returns the first invalid field as an error.
+func ValidateApp(app m.ContainerInfo, prefix string) error {
+	if invalid := ValidateContainer(app, prefix, config.Config.API.AllowedSchemes); len(invalid) > 0 {
+		return fmt.Errorf("%s %s", invalid[0].Field, invalid[0].Reason)
+	}
+
+	return nil
+}
+
+// validateUrl returns why a required URL is invalid, or an empty string.
+func validateUrl(rawUrl string, allowedSchemes []string) string {
+	if strings.TrimSpace(rawUrl) == "" {
+		return "is required"
+	}
+
+	if len(rawUrl) > maxUrlLength {
+		return fmt.Sprintf("must not be longer than %d characters", maxUrlLength)
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "must be a valid URL"
+	}
+
+	if !slices.Contains(allowedSchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Sprintf("must use one of the schemes: %s", strings.Join(allowedSchemes, ", "))
+	}
+
+	if u.Host == "" {
+		return "must contain a host"
+	}
+
+	return ""
+}
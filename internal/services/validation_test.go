@@ -0,0 +1,179 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+const testUuid = "14a107d2-db4b-4419-a7fe-f1499ad02ee7"
+
+func TestValidateContainerUpdate(t *testing.T) {
+	schemes := []string{"http", "https"}
+	valid := m.ContainerInfo{Name: "Jellyfin", Url: "https://jellyfin.example.com"}
+	with := func(change func(app *m.ContainerInfo)) m.ContainerInfo {
+		app := valid
+		change(&app)
+		return app
+	}
+
+	manyTags := []string{}
+	manyMetadata := map[string]string{}
+	for i := range 21 {
+		manyTags = append(manyTags, "tag"+strconv.Itoa(i))
+		manyMetadata["key"+strconv.Itoa(i)] = "value"
+	}
+
+	tests := []struct {
+		name     string
+		update   m.ContainerUpdate
+		expected []FieldError
+	}{
+		{
+			name:   "valid",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{valid}},
+		},
+		{
+			name: "every optional field",
+			update: m.ContainerUpdate{Uuid: strings.ToUpper(testUuid), Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.ID, app.Icon, app.Comment, app.Group, app.Tags = "jellyfin", "jellyfin", "Movies", "Media", []string{"video"}
+				app.Description, app.Target, app.Status = "Watch movies", m.TargetSameTab, m.StatusDegraded
+				app.InternalUrl, app.ExternalUrl = "http://10.0.0.5:8096", "HTTPS://jellyfin.example.com"
+				app.Metadata = map[string]string{"version": "10.9"}
+				app.Visibility = []m.VisibilityRule{{Networks: []string{"10.0.0.0/8", "192.168.1.1"}}}
+			})}},
+		},
+		{
+			name:   "no applications",
+			update: m.ContainerUpdate{Uuid: testUuid},
+		},
+		{
+			name:     "missing uuid",
+			update:   m.ContainerUpdate{Containers: []m.ContainerInfo{valid}},
+			expected: []FieldError{{"uuid", "is required"}},
+		},
+		{
+			name:     "invalid uuid",
+			update:   m.ContainerUpdate{Uuid: "sidecar-1"},
+			expected: []FieldError{{"uuid", "must be a UUID like 14a107d2-db4b-4419-a7fe-f1499ad02ee7"}},
+		},
+		{
+			name:     "too many applications",
+			update:   m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{valid, valid, valid}},
+			expected: []FieldError{{"containers", "must not contain more than 2 applications"}},
+		},
+		{
+			name: "missing name and url",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{valid, with(func(app *m.ContainerInfo) {
+				app.Name, app.Url = " ", ""
+			})}},
+			expected: []FieldError{{"containers[1].name", "is required"}, {"containers[1].url", "is required"}},
+		},
+		{
+			name: "url schemes",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.Url, app.InternalUrl, app.ExternalUrl = "javascript:alert(document.cookie)", "ftp://nas.lan", "data:text/html,hi"
+			})}},
+			expected: []FieldError{
+				{"containers[0].url", "must use one of the schemes: http, https"},
+				{"containers[0].internalUrl", "must use one of the schemes: http, https"},
+				{"containers[0].externalUrl", "must use one of the schemes: http, https"},
+			},
+		},
+		{
+			name: "invalid urls",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.Url, app.InternalUrl = "https://", "http://%zz"
+			})}},
+			expected: []FieldError{{"containers[0].url", "must contain a host"}, {"containers[0].internalUrl", "must be a valid URL"}},
+		},
+		{
+			name: "oversized fields",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.ID = strings.Repeat("i", 101)
+				app.Name = strings.Repeat("ñ", 101)
+				app.Url = "https://example.com/" + strings.Repeat("u", 2048)
+				app.Icon = strings.Repeat("i", 101)
+				app.Comment = strings.Repeat("c", 501)
+				app.Group = strings.Repeat("g", 101)
+				app.Tags = []string{"ok", strings.Repeat("t", 51)}
+				app.Description = strings.Repeat("d", 2001)
+				app.Metadata = map[string]string{strings.Repeat("k", 51): "v", "version": strings.Repeat("v", 201)}
+			})}},
+			expected: []FieldError{
+				{"containers[0].id", "must not be longer than 100 characters"},
+				{"containers[0].name", "must not be longer than 100 characters"},
+				{"containers[0].url", "must not be longer than 2048 characters"},
+				{"containers[0].icon", "must not be longer than 100 characters"},
+				{"containers[0].comment", "must not be longer than 500 characters"},
+				{"containers[0].group", "must not be longer than 100 characters"},
+				{"containers[0].tags[1]", "must not be longer than 50 characters"},
+				{"containers[0].description", "must not be longer than 2000 characters"},
+				{"containers[0].metadata", "keys must not be longer than 50 characters"},
+				{"containers[0].metadata.version", "must not be longer than 200 characters"},
+			},
+		},
+		{
+			name: "fields at their limit",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.Name = strings.Repeat("ñ", 100)
+				app.Tags = manyTags[:20]
+			})}},
+		},
+		{
+			name: "too many tags and metadata",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.Tags, app.Metadata = manyTags, manyMetadata
+			})}},
+			expected: []FieldError{
+				{"containers[0].tags", "must not contain more than 20 tags"},
+				{"containers[0].metadata", "must not contain more than 20 entries"},
+			},
+		},
+		{
+			name: "target, status and visibility",
+			update: m.ContainerUpdate{Uuid: testUuid, Containers: []m.ContainerInfo{with(func(app *m.ContainerInfo) {
+				app.Target, app.Status = "_parent", "sleeping"
+				app.Visibility = []m.VisibilityRule{{Networks: []string{"10.0.0.0/8"}}, {Networks: []string{"lan"}}}
+			})}},
+			expected: []FieldError{
+				{"containers[0].target", "must be _blank or _self"},
+				{"containers[0].status", "must be up, degraded, down or stale"},
+				{"containers[0].visibility[1].networks", "must be a list of CIDR ranges or addresses"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if invalid := ValidateContainerUpdate(test.update, 2, schemes); !reflect.DeepEqual(invalid, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, invalid)
+			}
+		})
+	}
+}
+
+func TestValidateContainerUpdateWithoutLimit(t *testing.T) {
+	update := m.ContainerUpdate{Uuid: testUuid}
+	for range 100 {
+		update.Containers = append(update.Containers, m.ContainerInfo{Name: "Jellyfin", Url: "https://jellyfin.example.com"})
+	}
+
+	if invalid := ValidateContainerUpdate(update, 0, []string{"https"}); invalid != nil {
+		t.Errorf("expected no limit on the number of applications, got %v", invalid)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/mvdkleijn/homedash/internal/auth"
+	"github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	"github.com/mvdkleijn/homedash/internal/network"
+)
+
+// Visitor holds what visibility rules can be matched against. Header should
+// only contain headers from trusted proxies.
+type Visitor struct {
+	Addr     netip.Addr
+	Host     string
+	Header   http.Header
+	Identity *auth.Identity
+}
+
+// NewVisitor describes the client of a request. Headers are only taken into
+// account when the request came from a trusted proxy, so they can't be spoofed.
+func NewVisitor(r *http.Request) *Visitor {
+	visitor := &Visitor{
+		Addr:     network.ClientAddr(r),
+		Host:     r.Host,
+		Header:   http.Header{},
+		Identity: auth.FromContext(r.Context()),
+	}
+
+	if network.IsTrustedProxy(r) {
+		visitor.Header = r.Header
+		if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+			visitor.Host = forwardedHost
+		}
+	}
+
+	return visitor
+}
+
+// FilterVisible returns the containers the visitor is allowed to see. Both the
+// rules of the application and those of its group must allow the visitor. The
+// rules themselves are removed from the result.
+func FilterVisible(containers []m.ContainerInfo, groups []m.Group, visitor *Visitor) []m.ContainerInfo {
+	groupRules := make(map[string][]m.VisibilityRule)
+	for _, group := range groups {
+		key := strings.ToLower(group.Name)
+		groupRules[key] = append(groupRules[key], group.Visibility...)
+	}
+
+	visible := []m.ContainerInfo{}
+
+	for _, container := range containers {
+		if !visitor.Allowed(container.Visibility) || !visitor.Allowed(groupRules[strings.ToLower(container.Group)]) {
+			continue
+		}
+
+		container.Visibility = nil
+		visible = append(visible, container)
+	}
+
+	return visible
+}
+
+// Allowed reports whether any of the rules matches the visitor. Without rules
+// everyone is allowed.
+func (v *Visitor) Allowed(rules []m.VisibilityRule) bool {
+	if len(rules) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(rules, v.matches)
+}
+
+func (v *Visitor) matches(rule m.VisibilityRule) bool {
+	if len(rule.Networks) > 0 {
+		prefixes, err := network.ParsePrefixes(rule.Networks)
+		if err != nil {
+			config.Logger.Warn().Err(err).Strs("networks", rule.Networks).Msg("ignoring visibility rule with invalid network")
+			return false
+		}
+
+		if !network.Contains(prefixes, v.Addr) {
+			return false
+		}
+	}
+
+	if len(rule.Hosts) > 0 && !slices.ContainsFunc(rule.Hosts, v.hostMatches) {
+		return false
+	}
+
+	if rule.Header != "" {
+		values := v.Header.Values(rule.Header)
+		if len(values) == 0 {
+			return false
+		}
+
+		if len(rule.Values) > 0 && !headerContains(values, rule.Values) {
+			return false
+		}
+	}
+
+	if len(rule.Users) > 0 && (v.Identity == nil || !slices.ContainsFunc(rule.Users, func(user string) bool {
+		return strings.EqualFold(user, v.Identity.User)
+	})) {
+		return false
+	}
+
+	if len(rule.Groups) > 0 && !slices.ContainsFunc(rule.Groups, v.Identity.InGroup) {
+		return false
+	}
+
+	return true
+}
+
+// hostMatches compares the visitor's host, ignoring the port, to a pattern
+// like "dash.home.arpa" or "*.home.arpa".
+func (v *Visitor) hostMatches(pattern string) bool {
+	host := v.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+
+	if suffix, found := strings.CutPrefix(pattern, "*."); found {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+
+	return host == pattern
+}
+
+// headerContains reports whether any of the comma separated header values is
+// one of the wanted values, as used by headers like Remote-Groups.
+func headerContains(headerValues []string, wanted []string) bool {
+	for _, headerValue := range headerValues {
+		for _, value := range strings.Split(headerValue, ",") {
+			value = strings.TrimSpace(value)
+			if slices.ContainsFunc(wanted, func(w string) bool { return strings.EqualFold(w, value) }) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
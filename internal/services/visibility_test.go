@@ -0,0 +1,143 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package services
+
+import (
+	"net/http"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/mvdkleijn/homedash/internal/auth"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+func TestFilterVisible(t *testing.T) {
+	containers := []m.ContainerInfo{
+		{Name: "Jellyfin", Group: "Media"},
+		{Name: "Router", Group: "Network", Visibility: []m.VisibilityRule{{Networks: []string{"192.168.0.0/16", "fd00::/8"}}}},
+		{Name: "Grafana", Visibility: []m.VisibilityRule{{Hosts: []string{"*.home.arpa"}}, {Hosts: []string{"dash.example.com"}}}},
+		{Name: "Vaultwarden", Visibility: []m.VisibilityRule{{Groups: []string{"admins"}}, {Users: []string{"alice"}}}},
+		{Name: "Paperless", Visibility: []m.VisibilityRule{{Networks: []string{"192.168.1.0/24"}, Groups: []string{"family"}}}},
+		{Name: "Proxmox", Visibility: []m.VisibilityRule{{Header: "Remote-Groups", Values: []string{"admins"}}}},
+		{Name: "Broken", Visibility: []m.VisibilityRule{{Networks: []string{"lan"}}}},
+	}
+	groups := []m.Group{
+		{Name: "media", Visibility: []m.VisibilityRule{{Networks: []string{"10.0.0.0/8", "192.168.0.0/16"}}}},
+	}
+
+	tests := []struct {
+		name     string
+		visitor  Visitor
+		expected []string
+	}{
+		{
+			name:     "anonymous from the internet",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Host: "dash.example.org"},
+			expected: []string{},
+		},
+		{
+			name:     "anonymous from the LAN",
+			visitor:  Visitor{Addr: netip.MustParseAddr("192.168.1.20"), Host: "dash.example.org"},
+			expected: []string{"Jellyfin", "Router"},
+		},
+		{
+			name:     "IPv6 LAN",
+			visitor:  Visitor{Addr: netip.MustParseAddr("fd00::20"), Host: "dash.example.org"},
+			expected: []string{"Router"},
+		},
+		{
+			name:     "IPv4-mapped address",
+			visitor:  Visitor{Addr: netip.MustParseAddr("::ffff:10.1.2.3"), Host: "dash.example.org"},
+			expected: []string{"Jellyfin"},
+		},
+		{
+			name:     "wildcard host with a port",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Host: "Dash.Home.Arpa:8080"},
+			expected: []string{"Grafana"},
+		},
+		{
+			name:     "exact host",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Host: "dash.example.com"},
+			expected: []string{"Grafana"},
+		},
+		{
+			name:     "wildcard doesn't match the domain itself",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Host: "home.arpa"},
+			expected: []string{},
+		},
+		{
+			name:     "group",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Identity: &auth.Identity{User: "bob", Groups: []string{"Admins"}}},
+			expected: []string{"Vaultwarden"},
+		},
+		{
+			name:     "user",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Identity: &auth.Identity{User: "Alice"}},
+			expected: []string{"Vaultwarden"},
+		},
+		{
+			name:     "group on the wrong network",
+			visitor:  Visitor{Addr: netip.MustParseAddr("192.168.2.20"), Identity: &auth.Identity{User: "carol", Groups: []string{"family"}}},
+			expected: []string{"Jellyfin", "Router"},
+		},
+		{
+			name:     "group on the right network",
+			visitor:  Visitor{Addr: netip.MustParseAddr("192.168.1.20"), Identity: &auth.Identity{User: "carol", Groups: []string{"family"}}},
+			expected: []string{"Jellyfin", "Router", "Paperless"},
+		},
+		{
+			name:     "header from a trusted proxy",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Header: http.Header{"Remote-Groups": {"users, admins"}}},
+			expected: []string{"Proxmox"},
+		},
+		{
+			name:     "header with other values",
+			visitor:  Visitor{Addr: netip.MustParseAddr("203.0.113.7"), Header: http.Header{"Remote-Groups": {"users"}}},
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.visitor.Header == nil {
+				test.visitor.Header = http.Header{}
+			}
+			visible := FilterVisible(containers, groups, &test.visitor)
+
+			names := []string{}
+			for _, container := range visible {
+				names = append(names, container.Name)
+				if container.Visibility != nil {
+					t.Errorf("expected the rules of %s to be removed", container.Name)
+				}
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestVisitorAllowed(t *testing.T) {
+	visitor := &Visitor{Addr: netip.MustParseAddr("192.168.1.20"), Header: http.Header{}}
+
+	if !visitor.Allowed(nil) {
+		t.Errorf("expected everyone to be allowed without rules")
+	}
+	if !visitor.Allowed([]m.VisibilityRule{{}}) {
+		t.Errorf("expected an empty rule to allow everyone")
+	}
+	if visitor.Allowed([]m.VisibilityRule{{Users: []string{"alice"}}}) {
+		t.Errorf("expected a user rule not to allow anonymous visitors")
+	}
+}
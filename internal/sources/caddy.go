@@ -0,0 +1,215 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// caddyConfig is the part of Caddy's JSON config that describes sites.
+type caddyConfig struct {
+	Apps struct {
+		HTTP struct {
+			Servers map[string]struct {
+				Listen []string `json:"listen"`
+				Routes []struct {
+					Match []struct {
+						Host []string `json:"host"`
+						Path []string `json:"path"`
+					} `json:"match"`
+				} `json:"routes"`
+			} `json:"servers"`
+		} `json:"http"`
+	} `json:"apps"`
+}
+
+// NewCaddy discovers the sites in Caddyfiles and Caddy JSON configs.
+func NewCaddy(config c.ConfigFileSourceConfiguration) (*fileSource, error) {
+	return newFileSource("caddy", config.Name, config.Paths, parseCaddyFile)
+}
+
+func parseCaddyFile(path string) ([]m.ContainerInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(path, ".json") {
+		return parseCaddyJSON(data)
+	}
+
+	return parseCaddyfile(data)
+}
+
+// parseCaddyfile reads the site blocks of a Caddyfile. Comments like
+// "# homedash.icon jellyfin" inside a site block describe the application.
+func parseCaddyfile(data []byte) ([]m.ContainerInfo, error) {
+	apps := []m.ContainerInfo{}
+
+	depth := 0
+	var addresses []string
+	var labels map[string]string
+	seenDirective := false
+
+	finishSite := func() {
+		if addresses != nil {
+			apps = append(apps, siteApps(labels, caddyUrls(addresses))...)
+		}
+		addresses, labels = nil, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#") {
+			if labels != nil {
+				commentLabel(line, labels)
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		opens := strings.HasSuffix(line, "{")
+		closes := strings.HasPrefix(line, "}")
+
+		switch {
+		case depth == 0 && opens:
+			head := strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			// The global options block and snippets aren't sites
+			if head != "" && !strings.HasPrefix(head, "(") {
+				finishSite()
+				addresses = caddyAddresses(head)
+				labels = map[string]string{}
+			}
+		case depth == 0 && !seenDirective && addresses == nil:
+			// A Caddyfile with a single site may leave out the braces
+			addresses = caddyAddresses(line)
+			labels = map[string]string{}
+		}
+		seenDirective = true
+
+		if closes {
+			if depth == 0 {
+				return nil, errors.New("unexpected }")
+			}
+			depth--
+			if depth == 0 {
+				finishSite()
+			}
+		}
+		if opens {
+			depth++
+		}
+	}
+	if depth > 0 {
+		return nil, errors.New("unexpected end of file, missing }")
+	}
+	finishSite()
+
+	return apps, nil
+}
+
+func caddyAddresses(head string) []string {
+	return strings.FieldsFunc(head, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+}
+
+// caddyUrls turns site addresses into URLs. Caddy serves host names over HTTPS
+// unless the address says otherwise.
+func caddyUrls(addresses []string) []string {
+	urls := []string{}
+
+	for _, address := range addresses {
+		scheme := "https"
+		if before, after, found := strings.Cut(address, "://"); found {
+			scheme, address = before, after
+		}
+
+		host, path, _ := strings.Cut(address, "/")
+		if path != "" {
+			path = "/" + strings.TrimSuffix(path, "*")
+		}
+
+		hostname, port, err := net.SplitHostPort(host)
+		if err != nil {
+			hostname, port = host, ""
+		}
+		if hostname == "" || strings.Contains(hostname, "{") {
+			continue
+		}
+		if port == "80" {
+			scheme = "http"
+		}
+		if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+			port = ""
+		}
+		if port != "" {
+			hostname = net.JoinHostPort(hostname, port)
+		}
+
+		urls = appendUrl(urls, scheme, hostname, path)
+	}
+
+	return urls
+}
+
+// parseCaddyJSON reads the hosts matched by the routes of Caddy's JSON config.
+func parseCaddyJSON(data []byte) ([]m.ContainerInfo, error) {
+	var config caddyConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	apps := []m.ContainerInfo{}
+	servers := config.Apps.HTTP.Servers
+	for _, name := range slices.Sorted(maps.Keys(servers)) {
+		server := servers[name]
+
+		// Servers only listening on port 80 don't get automatic HTTPS
+		scheme := "https"
+		if len(server.Listen) > 0 && !slices.ContainsFunc(server.Listen, func(listen string) bool {
+			return !strings.HasSuffix(listen, ":80")
+		}) {
+			scheme = "http"
+		}
+
+		for _, route := range server.Routes {
+			for _, match := range route.Match {
+				path := ""
+				if len(match.Path) > 0 && !strings.ContainsAny(strings.TrimSuffix(match.Path[0], "*"), "*") {
+					path = strings.TrimSuffix(match.Path[0], "*")
+				}
+
+				urls := []string{}
+				for _, host := range match.Host {
+					urls = appendUrl(urls, scheme, host, path)
+				}
+				apps = append(apps, siteApps(map[string]string{}, urls)...)
+			}
+		}
+	}
+
+	return apps, nil
+}
@@ -0,0 +1,131 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"reflect"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// appLabels returns the name, URL, icon and group of every application, for
+// comparing the labels applied to them in tests.
+func appLabels(apps []m.ContainerInfo) []string {
+	labels := []string{}
+	for _, app := range apps {
+		labels = append(labels, app.Name+" "+app.Url+" icon="+app.Icon+" group="+app.Group)
+	}
+
+	return labels
+}
+
+func TestParseCaddyfile(t *testing.T) {
+	withIcons(t, c.IconIndex{})
+
+	apps, err := parseCaddyFile("testdata/caddy/Caddyfile")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Options, snippets, catch-alls, wildcards, placeholders and disabled
+	// sites are left out
+	expected := []string{
+		"Jellyfin https://jellyfin.example.com/ icon= group=Media",
+		"git (git.example.com) https://git.example.com/ icon=gitea group=",
+		"git (code.example.com) https://code.example.com/ icon=gitea group=",
+		"printer http://printer.lan:8080/ icon= group=",
+		"example https://example.com/grafana/ icon=grafana group=",
+		"localhost https://localhost:8443/ icon= group=",
+	}
+	if labels := appLabels(apps); !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected %q, got %q", expected, labels)
+	}
+}
+
+func TestParseCaddyfileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		caddy    string
+		expected string
+	}{
+		{name: "unexpected brace", caddy: "example.com {\n}\n}\n", expected: "unexpected }"},
+		{name: "missing brace", caddy: "example.com {\n\thandle {\n}\n", expected: "unexpected end of file, missing }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseCaddyfile([]byte(test.caddy)); err == nil || err.Error() != test.expected {
+				t.Errorf("expected error %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseCaddyfileWithoutBraces(t *testing.T) {
+	apps, err := parseCaddyfile([]byte("# A single site\njellyfin.example.com\n\nreverse_proxy jellyfin:8096\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if urls := appUrls(apps); !reflect.DeepEqual(urls, []string{"jellyfin https://jellyfin.example.com/"}) {
+		t.Errorf("expected the site, got %v", urls)
+	}
+}
+
+func TestParseCaddyJSON(t *testing.T) {
+	withIcons(t, c.IconIndex{})
+
+	expected := []string{
+		"jellyfin https://jellyfin.example.com/",
+		"git (git.example.com) https://git.example.com/",
+		"git (code.example.com) https://code.example.com/",
+		"printer http://printer.lan/",
+		"grafana https://grafana.example.com/grafana/",
+	}
+
+	// Servers are read in order of their names, not in random map order
+	for range 10 {
+		apps, err := parseCaddyFile("testdata/caddy/caddy.json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if urls := appUrls(apps); !reflect.DeepEqual(urls, expected) {
+			t.Fatalf("expected %q, got %q", expected, urls)
+		}
+	}
+}
+
+func TestCaddyUrls(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected []string
+	}{
+		{address: "example.com", expected: []string{"https://example.com/"}},
+		{address: "http://example.com", expected: []string{"http://example.com/"}},
+		{address: "example.com:80", expected: []string{"http://example.com/"}},
+		{address: "https://example.com:443", expected: []string{"https://example.com/"}},
+		{address: "example.com:8443/app/*", expected: []string{"https://example.com:8443/app/"}},
+		{address: ":443", expected: []string{}},
+		{address: "{$DOMAIN}", expected: []string{}},
+		{address: "*.example.com", expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			if urls := caddyUrls([]string{test.address}); !reflect.DeepEqual(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
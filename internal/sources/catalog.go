@@ -0,0 +1,209 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// metaPrefix replaces labelPrefix in Consul meta keys and Prometheus labels,
+// which can't contain dots.
+const metaPrefix = "homedash_"
+
+// catalogInstance is a single registration of a service in Consul or Nomad.
+type catalogInstance struct {
+	Labels  map[string]string
+	Address string
+	Port    int
+	Status  string
+}
+
+// catalogApp builds the application of a service from its instances. The
+// labels of the first instance that has them are used, the URL defaults to
+// the addresses of the instances. It returns false when the service has no
+// homedash labels and all is not set, or when it is disabled.
+func catalogApp(service string, instances []catalogInstance, all bool) ([]m.ContainerInfo, bool) {
+	labels := map[string]string{}
+	for _, instance := range instances {
+		if len(instance.Labels) > 0 {
+			labels = instance.Labels
+			break
+		}
+	}
+
+	app, found := appFromLabels(labels)
+	if (!found && !all) || isDisabled(labels) {
+		return nil, false
+	}
+
+	if app.Name == "" {
+		app.Name = service
+	}
+	if app.Icon == "" {
+		app.Icon = c.GuessIcon(service)
+	}
+
+	// Without a URL of its own, there is an application for each address
+	// with the status of the instances there
+	urls := []string{}
+	statuses := map[string][]string{}
+	combined := []string{}
+	for _, instance := range instances {
+		if instance.Status != "" {
+			combined = append(combined, instance.Status)
+		}
+		if instance.Address == "" || instance.Port <= 0 {
+			continue
+		}
+		url := "http://" + net.JoinHostPort(instance.Address, strconv.Itoa(instance.Port))
+		if !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+		if instance.Status != "" {
+			statuses[url] = append(statuses[url], instance.Status)
+		}
+	}
+
+	if app.Url != "" {
+		app.Status = combineStatus(combined)
+		return []m.ContainerInfo{app}, true
+	}
+
+	apps := expandUrls(app, urls)
+	for i := range apps {
+		apps[i].Status = combineStatus(statuses[apps[i].Url])
+	}
+
+	return apps, true
+}
+
+// combineStatus returns the status shared by all instances, or degraded when
+// only some of them are up.
+func combineStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return ""
+	}
+
+	for _, status := range statuses[1:] {
+		if status != statuses[0] {
+			return m.StatusDegraded
+		}
+	}
+
+	return statuses[0]
+}
+
+// tagLabels reads labels from tags like homedash.url=https://example.com.
+func tagLabels(tags []string) map[string]string {
+	labels := map[string]string{}
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, labelPrefix) {
+			continue
+		}
+		key, value, _ := strings.Cut(tag, "=")
+		labels[key] = value
+	}
+
+	return labels
+}
+
+// metaLabels reads labels from keys like homedash_url. Metadata entries use
+// homedash_metadata_<key>.
+func metaLabels(meta map[string]string, labels map[string]string) {
+	for key, value := range meta {
+		field, found := strings.CutPrefix(key, metaPrefix)
+		if !found {
+			continue
+		}
+		if metadataKey, isMetadata := strings.CutPrefix(field, "metadata_"); isMetadata {
+			field = "metadata." + metadataKey
+		}
+		labels[labelPrefix+field] = value
+	}
+}
+
+// waitForAny runs a blocking query on each of the URLs and returns when the
+// first of them reports a change. The indexes are updated in place.
+func waitForAny(ctx context.Context, urls []string, indexes []uint64, wait time.Duration, indexHeader string, prepare func(*http.Request)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i     int
+		index uint64
+		err   error
+	}
+	results := make(chan result, len(urls))
+
+	for i, url := range urls {
+		go func() {
+			index, err := blockingQuery(ctx, url, indexes[i], wait, indexHeader, prepare)
+			results <- result{i, index, err}
+		}()
+	}
+
+	first := <-results
+	if first.err != nil {
+		return first.err
+	}
+	indexes[first.i] = first.index
+
+	// Keep the indexes of queries that returned at the same time, the others
+	// are canceled
+	cancel()
+	for range len(urls) - 1 {
+		if other := <-results; other.err == nil {
+			indexes[other.i] = other.index
+		}
+	}
+
+	return nil
+}
+
+// checkStatus maps the status of a Consul or Nomad health check to an
+// application status. Pending and unknown checks have no status.
+func checkStatus(status string) string {
+	switch status {
+	case "passing", "success":
+		return m.StatusUp
+	case "warning":
+		return m.StatusDegraded
+	case "critical", "maintenance", "failure":
+		return m.StatusDown
+	}
+
+	return ""
+}
+
+// worstStatus returns the status of an instance from those of its checks.
+func worstStatus(statuses []string) string {
+	worst := ""
+	for _, status := range statuses {
+		switch {
+		case status == m.StatusDown:
+			return status
+		case status == m.StatusDegraded, worst == "":
+			worst = status
+		}
+	}
+
+	return worst
+}
@@ -0,0 +1,153 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// catalogStandIn serves fixed Consul or Nomad responses by path, with the
+// index of the data in indexHeader. Requests with an index wait until the
+// data changes after it, like blocking queries do.
+type catalogStandIn struct {
+	*httptest.Server
+
+	tokenHeader string
+	indexHeader string
+
+	mu        sync.Mutex
+	responses map[string]string
+	queries   map[string]string
+	blocking  map[string]string
+	index     uint64
+	changed   chan struct{}
+}
+
+func newCatalogStandIn(t *testing.T, tokenHeader string, indexHeader string, responses map[string]string) *catalogStandIn {
+	standIn := &catalogStandIn{
+		tokenHeader: tokenHeader,
+		indexHeader: indexHeader,
+		responses:   responses,
+		queries:     map[string]string{},
+		blocking:    map[string]string{},
+		index:       10,
+		changed:     make(chan struct{}),
+	}
+	standIn.Server = httptest.NewServer(http.HandlerFunc(standIn.serve))
+	t.Cleanup(standIn.Close)
+
+	return standIn
+}
+
+// set replaces the response for the path and wakes the blocking queries.
+func (standIn *catalogStandIn) set(path string, response string) {
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	standIn.responses[path] = response
+	standIn.index++
+	close(standIn.changed)
+	standIn.changed = make(chan struct{})
+}
+
+// query returns the query string of the last request for the path.
+func (standIn *catalogStandIn) query(path string) string {
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	return standIn.queries[path]
+}
+
+// lastBlockingQuery returns the query string of the last blocking query for
+// the path.
+func (standIn *catalogStandIn) lastBlockingQuery(path string) string {
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	return standIn.blocking[path]
+}
+
+func (standIn *catalogStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(standIn.tokenHeader) != "test-token" {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+		return
+	}
+
+	standIn.mu.Lock()
+	standIn.queries[r.URL.Path] = r.URL.RawQuery
+	if r.URL.Query().Has("index") {
+		standIn.blocking[r.URL.Path] = r.URL.RawQuery
+	}
+	index := standIn.index
+	changed := standIn.changed
+	standIn.mu.Unlock()
+
+	if since, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); err == nil && since >= index {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	standIn.mu.Lock()
+	response, exists := standIn.responses[r.URL.Path]
+	index = standIn.index
+	standIn.mu.Unlock()
+
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set(standIn.indexHeader, strconv.FormatUint(index, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(response))
+}
+
+// appStatuses returns the name, URL and status of every application, for
+// comparing them in tests.
+func appStatuses(apps []m.ContainerInfo) []string {
+	statuses := []string{}
+	for _, app := range apps {
+		statuses = append(statuses, app.Name+" "+app.Url+" "+app.Status)
+	}
+
+	return statuses
+}
+
+func TestWorstStatus(t *testing.T) {
+	tests := []struct {
+		statuses []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"", m.StatusUp}, m.StatusUp},
+		{[]string{m.StatusUp, m.StatusDegraded, m.StatusUp}, m.StatusDegraded},
+		{[]string{m.StatusDegraded, m.StatusDown, m.StatusUp}, m.StatusDown},
+	}
+
+	for _, test := range tests {
+		if status := worstStatus(test.statuses); status != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.statuses, test.expected, status)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// composeFileNames are the names Docker Compose looks for in a directory.
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+type composeProject struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image         string        `yaml:"image"`
+	ContainerName string        `yaml:"container_name"`
+	Labels        composeLabels `yaml:"labels"`
+	Deploy        struct {
+		Labels composeLabels `yaml:"labels"`
+	} `yaml:"deploy"`
+}
+
+// composeLabels can be written as a list of key=value items or as a map.
+type composeLabels map[string]string
+
+func (l *composeLabels) UnmarshalYAML(node *yaml.Node) error {
+	labels := composeLabels{}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, _ := strings.Cut(item.Value, "=")
+			labels[key] = value
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			labels[node.Content[i].Value] = node.Content[i+1].Value
+		}
+	default:
+		return fmt.Errorf("line %d: labels must be a list or a map", node.Line)
+	}
+
+	*l = labels
+	return nil
+}
+
+// NewCompose returns a source for Docker Compose files, for hosts where the
+// sidecar can't use the Docker socket. Directories are searched for compose
+// files, also in their subdirectories.
+func NewCompose(config c.ConfigFileSourceConfiguration) (*fileSource, error) {
+	source, err := newFileSource("compose", config.Name, config.Paths, parseComposeFile)
+	if err != nil {
+		return nil, err
+	}
+	source.expand = expandComposePaths
+
+	return source, nil
+}
+
+// expandComposePaths returns the compose files matching the paths. For
+// directories, these are the compose files in them and in the directories
+// directly below them, like /opt/stacks/<stack>/compose.yaml.
+func expandComposePaths(paths []string) []string {
+	files := []string{}
+
+	for _, pattern := range paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			c.Logger.Warn().Err(err).Str("path", pattern).Msg("invalid path pattern")
+			continue
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				continue
+			}
+			if !info.IsDir() {
+				files = append(files, match)
+				continue
+			}
+
+			directories := []string{match}
+			if entries, err := os.ReadDir(match); err == nil {
+				for _, entry := range entries {
+					if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+						directories = append(directories, filepath.Join(match, entry.Name()))
+					}
+				}
+			}
+
+			for _, directory := range directories {
+				for _, name := range composeFileNames {
+					if info, err := os.Stat(filepath.Join(directory, name)); err == nil && info.Mode().IsRegular() {
+						files = append(files, filepath.Join(directory, name))
+					}
+				}
+			}
+		}
+	}
+
+	slices.Sort(files)
+
+	return slices.Compact(files)
+}
+
+func parseComposeFile(path string) ([]m.ContainerInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	project := composeProject{}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, err
+	}
+
+	apps := []m.ContainerInfo{}
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		apps = append(apps, composeServiceApps(name, project.Services[name])...)
+	}
+
+	return apps, nil
+}
+
+// composeServiceApps builds the applications of a service with homedash.*
+// labels, like the sidecar does for a container. Without homedash.url, the
+// Host rules of its Traefik routers are used.
+func composeServiceApps(name string, service composeService) []m.ContainerInfo {
+	labels := maps.Clone(service.Deploy.Labels)
+	if labels == nil {
+		labels = composeLabels{}
+	}
+	maps.Copy(labels, service.Labels)
+
+	app, found := appFromLabels(labels)
+	if !found {
+		return nil
+	}
+
+	if app.Name == "" {
+		app.Name = service.ContainerName
+	}
+	if app.Name == "" {
+		app.Name = name
+	}
+
+	// The image name without registry and tag, like jellyfin for
+	// lscr.io/linuxserver/jellyfin:latest
+	image := service.Image
+	if at := strings.LastIndexAny(image, ":@"); at > strings.LastIndex(image, "/") {
+		image = image[:at]
+	}
+	image = image[strings.LastIndex(image, "/")+1:]
+
+	if app.Icon == "" {
+		app.Icon = c.GuessIcon(image, name)
+	}
+	if service.Image != "" && app.Metadata["image"] == "" {
+		if app.Metadata == nil {
+			app.Metadata = map[string]string{}
+		}
+		app.Metadata["image"] = service.Image
+	}
+
+	return expandUrls(app, traefikLabelUrls(labels))
+}
+
+// traefikLabelUrls returns the URLs of the Host rules of the Traefik routers
+// in the labels. Routers with TLS use https.
+func traefikLabelUrls(labels map[string]string) []string {
+	urls := []string{}
+	if strings.EqualFold(labels["traefik.enable"], "false") {
+		return urls
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		router, isRouter := strings.CutPrefix(key, "traefik.http.routers.")
+		router, isRule := strings.CutSuffix(router, ".rule")
+		if !isRouter || !isRule || strings.Contains(router, ".") {
+			continue
+		}
+
+		prefix := "traefik.http.routers." + router + ".tls"
+		scheme := "http"
+		if strings.EqualFold(labels[prefix], "true") || slices.ContainsFunc(slices.Collect(maps.Keys(labels)), func(key string) bool {
+			return strings.HasPrefix(key, prefix+".")
+		}) {
+			scheme = "https"
+		}
+
+		for _, url := range ruleUrls(labels[key], scheme) {
+			if !slices.Contains(urls, url) {
+				urls = append(urls, url)
+			}
+		}
+	}
+
+	return urls
+}
@@ -0,0 +1,194 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+func TestParseComposeFile(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg", "sonarr": "sonarr.svg", "gitea": "gitea.svg"})
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{
+			path: "testdata/compose/media/compose.yaml",
+			expected: []string{
+				"jellyfin (jellyfin.example.com) https://jellyfin.example.com/ icon=jellyfin group=Media",
+				"jellyfin (tv.example.com) https://tv.example.com/ icon=jellyfin group=Media",
+				"Sonarr http://media.lan/sonarr icon=sonarr group=",
+			},
+		},
+		{
+			path: "testdata/compose/tools/docker-compose.yml",
+			expected: []string{
+				"gitea https://git.example.com icon=gitea group=Development",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			apps, err := parseComposeFile(test.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if labels := appLabels(apps); !slices.Equal(labels, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, labels)
+			}
+		})
+	}
+}
+
+func TestParseComposeFileMetadata(t *testing.T) {
+	withIcons(t, c.IconIndex{})
+
+	apps, err := parseComposeFile("testdata/compose/media/compose.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if image := apps[0].Metadata["image"]; image != "lscr.io/linuxserver/jellyfin:10.9.11" {
+		t.Errorf("expected the image in the metadata, got %q", image)
+	}
+	if apps[2].Comment != "TV series" {
+		t.Errorf("expected the comment from the map labels, got %q", apps[2].Comment)
+	}
+}
+
+func TestComposeLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected composeLabels
+		err      bool
+	}{
+		{"list", "[ homedash.name=Jellyfin, homedash.tags=a=b ]", composeLabels{"homedash.name": "Jellyfin", "homedash.tags": "a=b"}, false},
+		{"list without value", "[ homedash.enable ]", composeLabels{"homedash.enable": ""}, false},
+		{"map", "{ homedash.name: Jellyfin, homedash.enable: true }", composeLabels{"homedash.name": "Jellyfin", "homedash.enable": "true"}, false},
+		{"scalar", "homedash.name=Jellyfin", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			labels := composeLabels{}
+			err := yaml.Unmarshal([]byte(test.yaml), &labels)
+			if (err != nil) != test.err {
+				t.Fatalf("expected an error to be %v, got %v", test.err, err)
+			}
+			if !test.err && !maps.Equal(labels, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, labels)
+			}
+		})
+	}
+}
+
+func TestTraefikLabelUrls(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected []string
+	}{
+		{
+			name:     "host",
+			labels:   map[string]string{"traefik.http.routers.app.rule": "Host(`app.example.com`)"},
+			expected: []string{"http://app.example.com/"},
+		},
+		{
+			name:     "path prefix",
+			labels:   map[string]string{"traefik.http.routers.app.rule": "Host(`lan`) && PathPrefix(`/app`)"},
+			expected: []string{"http://lan/app"},
+		},
+		{
+			name: "tls",
+			labels: map[string]string{
+				"traefik.http.routers.app.rule": "Host(`app.example.com`)",
+				"traefik.http.routers.app.tls":  "true",
+			},
+			expected: []string{"https://app.example.com/"},
+		},
+		{
+			name: "tls options",
+			labels: map[string]string{
+				"traefik.http.routers.app.rule":             "Host(`app.example.com`)",
+				"traefik.http.routers.app.tls.certresolver": "letsencrypt",
+			},
+			expected: []string{"https://app.example.com/"},
+		},
+		{
+			name: "tls on another router",
+			labels: map[string]string{
+				"traefik.http.routers.app.rule":      "Host(`app.example.com`)",
+				"traefik.http.routers.app-tls.rule":  "Host(`secure.example.com`)",
+				"traefik.http.routers.app-tls.tls":   "true",
+				"traefik.http.routers.app.tls":       "false",
+				"traefik.http.routers.app.service":   "app",
+				"traefik.http.routers.app.priority":  "10",
+				"traefik.http.services.app.rule":     "Host(`service.example.com`)",
+				"traefik.tcp.routers.app.rule":       "HostSNI(`tcp.example.com`)",
+				"traefik.http.routers.app.sub.rule":  "Host(`nested.example.com`)",
+				"traefik.http.routers.app.rule.test": "Host(`suffix.example.com`)",
+			},
+			expected: []string{"https://secure.example.com/", "http://app.example.com/"},
+		},
+		{
+			name: "duplicate hosts",
+			labels: map[string]string{
+				"traefik.http.routers.a.rule": "Host(`app.example.com`)",
+				"traefik.http.routers.b.rule": "Host(`APP.example.com`) || Host(`other.example.com`)",
+			},
+			expected: []string{"http://app.example.com/", "http://other.example.com/"},
+		},
+		{
+			name:     "host regexp",
+			labels:   map[string]string{"traefik.http.routers.app.rule": "HostRegexp(`^.+\\.example\\.com$`)"},
+			expected: []string{},
+		},
+		{
+			name: "disabled",
+			labels: map[string]string{
+				"traefik.enable":                "False",
+				"traefik.http.routers.app.rule": "Host(`app.example.com`)",
+			},
+			expected: []string{},
+		},
+		{
+			name:     "no routers",
+			labels:   map[string]string{"homedash.name": "App"},
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if urls := traefikLabelUrls(test.labels); !slices.Equal(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
+
+func TestExpandComposePaths(t *testing.T) {
+	expected := []string{"testdata/compose/media/compose.yaml", "testdata/compose/tools/docker-compose.yml"}
+
+	if files := expandComposePaths([]string{"testdata/compose", "testdata/compose/*/compose.yaml"}); !slices.Equal(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
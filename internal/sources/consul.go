@@ -0,0 +1,170 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// consulIndexHeader holds the index of a Consul response, for blocking queries.
+const consulIndexHeader = "X-Consul-Index"
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Service string            `json:"Service"`
+		Tags    []string          `json:"Tags"`
+		Meta    map[string]string `json:"Meta"`
+		Address string            `json:"Address"`
+		Port    int               `json:"Port"`
+	} `json:"Service"`
+	Checks []struct {
+		Status string `json:"Status"`
+	} `json:"Checks"`
+}
+
+// Consul discovers applications from the services in a Consul catalog. The
+// status of an application follows the health checks of its instances.
+type Consul struct {
+	poller
+	config c.ConsulSourceConfiguration
+
+	// indexes of the blocking queries on the catalog and the health checks
+	indexes []uint64
+}
+
+func NewConsul(config c.ConsulSourceConfiguration) (*Consul, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Url == "" {
+		return nil, fmt.Errorf("consul source %s: url is required", config.Name)
+	}
+	if config.Interval <= 0 {
+		config.Interval = 300
+	}
+
+	return &Consul{
+		poller:  newPoller("consul", config.Name),
+		config:  config,
+		indexes: make([]uint64, 2),
+	}, nil
+}
+
+func (cs *Consul) Watch(ctx context.Context, onChange func()) error {
+	cs.setOnChange(onChange)
+	go cs.run(ctx)
+
+	return nil
+}
+
+// run fetches the services whenever a blocking query reports a change.
+func (cs *Consul) run(ctx context.Context) {
+	for {
+		apps, err := cs.fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		cs.update(apps, err)
+
+		if err == nil {
+			err = waitForAny(ctx, []string{cs.endpoint("/v1/catalog/services"), cs.endpoint("/v1/health/state/any")},
+				cs.indexes, time.Duration(cs.config.Interval)*time.Second, consulIndexHeader, cs.authorize)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.Logger.Debug().Err(err).Str("source", cs.id).Msg("waiting before fetching services again")
+			if !sleep(ctx, retryInterval) {
+				return
+			}
+		}
+	}
+}
+
+func (cs *Consul) fetch(ctx context.Context) ([]m.ContainerInfo, error) {
+	services := map[string][]string{}
+	if _, err := getJSON(ctx, httpClient, cs.endpoint("/v1/catalog/services"), cs.authorize, &services); err != nil {
+		return nil, err
+	}
+
+	apps := []m.ContainerInfo{}
+	for _, service := range slices.Sorted(maps.Keys(services)) {
+		// Consul registers itself, with its RPC port
+		if service == "consul" {
+			continue
+		}
+
+		entries := []consulServiceEntry{}
+		if _, err := getJSON(ctx, httpClient, cs.endpoint("/v1/health/service/"+url.PathEscape(service)), cs.authorize, &entries); err != nil {
+			return nil, err
+		}
+
+		instances := []catalogInstance{}
+		for _, entry := range entries {
+			labels := tagLabels(entry.Service.Tags)
+			metaLabels(entry.Service.Meta, labels)
+
+			address := entry.Service.Address
+			if address == "" {
+				address = entry.Node.Address
+			}
+
+			statuses := []string{}
+			for _, check := range entry.Checks {
+				statuses = append(statuses, checkStatus(check.Status))
+			}
+
+			instances = append(instances, catalogInstance{
+				Labels:  labels,
+				Address: address,
+				Port:    entry.Service.Port,
+				Status:  worstStatus(statuses),
+			})
+		}
+
+		if serviceApps, ok := catalogApp(service, instances, cs.config.All); ok {
+			apps = append(apps, serviceApps...)
+		}
+	}
+
+	return apps, nil
+}
+
+// endpoint returns the URL of an API path in the configured datacenter.
+func (cs *Consul) endpoint(path string) string {
+	endpoint := strings.TrimSuffix(cs.config.Url, "/") + path
+	if cs.config.Datacenter != "" {
+		endpoint += "?dc=" + url.QueryEscape(cs.config.Datacenter)
+	}
+
+	return endpoint
+}
+
+func (cs *Consul) authorize(request *http.Request) {
+	if cs.config.Token != "" {
+		request.Header.Set("X-Consul-Token", cs.config.Token)
+	}
+}
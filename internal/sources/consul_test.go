@@ -0,0 +1,187 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+const consulServices = `{
+	"consul": [],
+	"grafana": [],
+	"hidden": ["homedash.enable=false"],
+	"jellyfin": ["homedash.name=Jellyfin", "homedash.icon=jellyfin"],
+	"redis": ["primary"]
+}`
+
+// newConsulStandIn serves a catalog with a Jellyfin service on two nodes, a
+// Grafana service with homedash_* meta and services without labels.
+func newConsulStandIn(t *testing.T) *catalogStandIn {
+	return newCatalogStandIn(t, "X-Consul-Token", consulIndexHeader, map[string]string{
+		"/v1/catalog/services": consulServices,
+		"/v1/health/state/any": `[]`,
+		"/v1/health/service/consul": `[
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Service": "consul", "Port": 8300}}
+		]`,
+		"/v1/health/service/grafana": `[
+			{
+				"Node": {"Address": "10.0.0.2"},
+				"Service": {"Service": "grafana", "Meta": {"homedash_url": "https://grafana.example.com", "homedash_metadata_team": "ops"}, "Port": 3000},
+				"Checks": [{"Status": "passing"}, {"Status": "warning"}]
+			},
+			{
+				"Node": {"Address": "10.0.0.3"},
+				"Service": {"Service": "grafana", "Port": 3000},
+				"Checks": [{"Status": "passing"}]
+			}
+		]`,
+		"/v1/health/service/hidden": `[
+			{"Node": {"Address": "10.0.0.2"}, "Service": {"Service": "hidden", "Tags": ["homedash.enable=false"], "Port": 80}}
+		]`,
+		"/v1/health/service/jellyfin": `[
+			{
+				"Node": {"Address": "10.0.0.2"},
+				"Service": {"Service": "jellyfin", "Tags": ["homedash.name=Jellyfin", "homedash.icon=jellyfin"], "Address": "192.168.1.20", "Port": 8096},
+				"Checks": [{"Status": "passing"}, {"Status": "passing"}]
+			},
+			{
+				"Node": {"Address": "10.0.0.3"},
+				"Service": {"Service": "jellyfin", "Tags": ["homedash.name=Jellyfin", "homedash.icon=jellyfin"], "Port": 8096},
+				"Checks": [{"Status": "passing"}, {"Status": "critical"}]
+			}
+		]`,
+		"/v1/health/service/redis": `[
+			{"Node": {"Address": "10.0.0.4"}, "Service": {"Service": "redis", "Tags": ["primary"], "Port": 6379}, "Checks": [{"Status": "passing"}]}
+		]`,
+	})
+}
+
+func TestConsulServices(t *testing.T) {
+	standIn := newConsulStandIn(t)
+
+	tests := []struct {
+		name     string
+		config   c.ConsulSourceConfiguration
+		expected []string
+	}{
+		{
+			name: "labeled services",
+			expected: []string{
+				"grafana https://grafana.example.com degraded",
+				"Jellyfin (192.168.1.20:8096) http://192.168.1.20:8096 up",
+				"Jellyfin (10.0.0.3:8096) http://10.0.0.3:8096 down",
+			},
+		},
+		{
+			name:   "all services",
+			config: c.ConsulSourceConfiguration{All: true},
+			expected: []string{
+				"grafana https://grafana.example.com degraded",
+				"Jellyfin (192.168.1.20:8096) http://192.168.1.20:8096 up",
+				"Jellyfin (10.0.0.3:8096) http://10.0.0.3:8096 down",
+				"redis http://10.0.0.4:6379 up",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Url = standIn.URL
+			test.config.Token = "test-token"
+			consul, err := NewConsul(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			apps, err := consul.fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statuses := appStatuses(apps); !slices.Equal(statuses, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, statuses)
+			}
+		})
+	}
+}
+
+func TestConsulMetadata(t *testing.T) {
+	standIn := newConsulStandIn(t)
+
+	consul, err := NewConsul(c.ConsulSourceConfiguration{Url: standIn.URL, Token: "test-token", Datacenter: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apps, err := consul.fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) == 0 || apps[0].Metadata["team"] != "ops" {
+		t.Errorf("expected the team metadata from the Grafana meta, got %v", apps)
+	}
+	if query, _ := url.ParseQuery(standIn.query("/v1/health/service/grafana")); query.Get("dc") != "home" {
+		t.Errorf("expected the datacenter in the query, got %v", query)
+	}
+}
+
+func TestConsulBlockingQueries(t *testing.T) {
+	standIn := newConsulStandIn(t)
+
+	consul, err := NewConsul(c.ConsulSourceConfiguration{Url: standIn.URL, Token: "test-token", Interval: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	changes := make(chan struct{}, 10)
+	if err := consul.Watch(ctx, func() { changes <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, changes)
+
+	standIn.set("/v1/health/service/nas", `[{"Node": {"Address": "10.0.0.5"}, "Service": {"Service": "nas", "Tags": ["homedash.icon=synology"], "Port": 5000}}]`)
+	standIn.set("/v1/catalog/services", strings.Replace(consulServices, `"redis"`, `"nas": ["homedash.icon=synology"], "redis"`, 1))
+
+	waitForChange(t, changes)
+	if statuses := appStatuses(consul.List()); !slices.Contains(statuses, "nas http://10.0.0.5:5000 ") {
+		t.Errorf("expected the new nas service, got %v", statuses)
+	}
+
+	query, _ := url.ParseQuery(standIn.lastBlockingQuery("/v1/catalog/services"))
+	if query.Get("index") == "" || query.Get("wait") != "30s" {
+		t.Errorf("expected a blocking query, got %v", query)
+	}
+	if err := consul.Health(); err != nil {
+		t.Errorf("expected a healthy source, got %v", err)
+	}
+}
+
+func TestConsulForbidden(t *testing.T) {
+	standIn := newConsulStandIn(t)
+
+	consul, err := NewConsul(c.ConsulSourceConfiguration{Url: standIn.URL, Token: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := consul.fetch(context.Background()); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
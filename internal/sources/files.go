@@ -0,0 +1,235 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// reloadDelay groups the burst of events caused by writing a file into a
+// single reload.
+const reloadDelay = 250 * time.Millisecond
+
+// fileSource discovers applications by parsing files. The paths can be files,
+// directories or glob patterns, and are watched for changes.
+type fileSource struct {
+	poller
+	paths  []string
+	expand func(paths []string) []string
+	parse  func(path string) ([]m.ContainerInfo, error)
+}
+
+func newFileSource(name string, instance string, paths []string, parse func(string) ([]m.ContainerInfo, error)) (*fileSource, error) {
+	if instance == "" {
+		instance = "default"
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s source %s: paths are required", name, instance)
+	}
+
+	return &fileSource{
+		poller: newPoller(name, instance),
+		paths:  paths,
+		expand: expandPaths,
+		parse:  parse,
+	}, nil
+}
+
+func (fs *fileSource) Watch(ctx context.Context, onChange func()) error {
+	fs.setOnChange(onChange)
+	fs.load()
+
+	// Also watch the directories of the files found, for glob patterns and
+	// sources that look in subdirectories
+	return watchFiles(ctx, append(slices.Clone(fs.paths), fs.expand(fs.paths)...), fs.load)
+}
+
+// load parses every file. Applications of the files that could be parsed are
+// kept, the errors of the others are reported together.
+func (fs *fileSource) load() {
+	apps := []m.ContainerInfo{}
+	errs := []error{}
+
+	for _, file := range fs.expand(fs.paths) {
+		fileApps, err := fs.parse(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		apps = append(apps, fileApps...)
+	}
+
+	fs.update(apps, errors.Join(errs...))
+}
+
+// expandPaths returns the files matching the paths. Directories are expanded
+// to the regular files in them, hidden and backup files are skipped.
+func expandPaths(paths []string) []string {
+	files := []string{}
+
+	for _, pattern := range paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			c.Logger.Warn().Err(err).Str("path", pattern).Msg("invalid path pattern")
+			continue
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				continue
+			}
+
+			if !info.IsDir() {
+				files = append(files, match)
+				continue
+			}
+
+			entries, err := os.ReadDir(match)
+			if err != nil {
+				c.Logger.Warn().Err(err).Str("path", match).Msg("failed to read directory")
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+					continue
+				}
+				if info, err := os.Stat(filepath.Join(match, name)); err == nil && info.Mode().IsRegular() {
+					files = append(files, filepath.Join(match, name))
+				}
+			}
+		}
+	}
+
+	slices.Sort(files)
+
+	return slices.Compact(files)
+}
+
+// watchFiles calls reload whenever something changes in the directories of the
+// paths, until ctx is done. Directories are watched instead of files, as
+// editors often replace files instead of writing to them.
+func watchFiles(ctx context.Context, paths []string, reload func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	directories := []string{}
+	for _, path := range paths {
+		directory := path
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			directory = filepath.Dir(path)
+		}
+
+		if slices.Contains(directories, directory) {
+			continue
+		}
+		directories = append(directories, directory)
+
+		if err := watcher.Add(directory); err != nil {
+			c.Logger.Warn().Err(err).Str("path", directory).Msg("not watching for changes")
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				c.Logger.Debug().Str("file", event.Name).Str("op", event.Op.String()).Msg("file changed")
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, reload)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				c.Logger.Error().Err(err).Msg("error watching files")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// commentLabel reads a "homedash.<key> <value>" label from a comment, also
+// accepting "=" or ":" between key and value.
+func commentLabel(comment string, labels map[string]string) {
+	comment = strings.TrimSpace(strings.TrimLeft(comment, "#/ "))
+	if !strings.HasPrefix(comment, labelPrefix) {
+		return
+	}
+
+	end := strings.IndexAny(comment, " \t=:")
+	if end < 0 {
+		return
+	}
+
+	labels[comment[:end]] = strings.TrimSpace(strings.TrimLeft(comment[end:], " \t=:"))
+}
+
+// siteApps turns the hosts of a site in a proxy config into applications. The
+// name defaults to the first part of the first host name.
+func siteApps(labels map[string]string, urls []string) []m.ContainerInfo {
+	app, found := appFromLabels(labels)
+	if !found && isDisabled(labels) {
+		return nil
+	}
+	if len(urls) == 0 && app.Url == "" {
+		return nil
+	}
+
+	if app.Name == "" {
+		host := app.Url
+		if host == "" {
+			host = urls[0]
+		}
+		// Leave out the port and path, like for a site at localhost:8443
+		if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+			host = u.Hostname()
+		}
+		app.Name, _, _ = strings.Cut(host, ".")
+	}
+	if app.Icon == "" {
+		app.Icon = c.GuessIcon(app.Name)
+	}
+
+	return expandUrls(app, urls)
+}
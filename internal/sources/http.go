@@ -0,0 +1,93 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeout limits requests to the backends of polling sources.
+const requestTimeout = 15 * time.Second
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// blockingClient is used for requests that wait for changes. These get a
+// deadline from their context instead.
+var blockingClient = &http.Client{}
+
+// getJSON fetches a URL and decodes the JSON response into value. The prepare
+// function can add authentication to the request. It returns the response
+// headers, for backends that use them for paging or change detection.
+func getJSON(ctx context.Context, client *http.Client, url string, prepare func(*http.Request), value any) (http.Header, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if prepare != nil {
+		prepare(request)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return response.Header, fmt.Errorf("unexpected status %s from %s", response.Status, request.URL.Redacted())
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(value); err != nil {
+		return response.Header, fmt.Errorf("invalid response from %s: %w", request.URL.Redacted(), err)
+	}
+
+	return response.Header, nil
+}
+
+// blockingQuery performs a Consul or Nomad style blocking query, which returns
+// when the data changed after index or the wait time passed. It returns the
+// index from the indexHeader of the response.
+func blockingQuery(ctx context.Context, url string, index uint64, wait time.Duration, indexHeader string, prepare func(*http.Request)) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait+requestTimeout)
+	defer cancel()
+
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	url += fmt.Sprintf("%sindex=%d&wait=%ds", separator, index, int(wait.Seconds()))
+
+	var discard json.RawMessage
+	header, err := getJSON(ctx, blockingClient, url, prepare, &discard)
+	if err != nil {
+		return index, err
+	}
+
+	newIndex, err := strconv.ParseUint(header.Get(indexHeader), 10, 64)
+	if err != nil {
+		return index, fmt.Errorf("missing %s header in response to a blocking query", indexHeader)
+	}
+
+	// The index can go backwards, for example after restoring a snapshot
+	if newIndex < index {
+		return 0, nil
+	}
+
+	return newIndex, nil
+}
@@ -0,0 +1,324 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// watchTimeout makes the API server end a watch now and then, after which
+	// the objects are listed again.
+	watchTimeout = 5 * time.Minute
+)
+
+var errKubeNotFound = errors.New("not found")
+
+// kubeClient is a minimal client for the Kubernetes API, just enough to list
+// and watch objects.
+type kubeClient struct {
+	server    string
+	client    *http.Client
+	token     string
+	tokenFile string
+}
+
+// kubeconfig is the part of a kubeconfig file HomeDash understands. Exec and
+// auth provider plugins are not supported.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Exec                  any    `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// newKubeClient uses the kubeconfig when given, the in-cluster service account
+// when running in a pod, or $KUBECONFIG or ~/.kube/config otherwise.
+func newKubeClient(kubeconfigPath string, contextName string) (*kubeClient, error) {
+	if kubeconfigPath != "" {
+		return kubeconfigClient(kubeconfigPath, contextName)
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return inClusterClient()
+	}
+
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return kubeconfigClient(filepath.SplitList(env)[0], contextName)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		return kubeconfigClient(filepath.Join(home, ".kube", "config"), contextName)
+	}
+
+	return nil, errors.New("not running in a cluster and no kubeconfig configured")
+}
+
+func inClusterClient() (*kubeClient, error) {
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account: %w", err)
+	}
+
+	tlsConfig := &tls.Config{RootCAs: x509.NewCertPool()}
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid service account CA certificate")
+	}
+
+	server := "https://" + net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+
+	// The token is read on every request, as it is rotated
+	return &kubeClient{
+		server:    server,
+		client:    newHTTPClient(tlsConfig),
+		tokenFile: filepath.Join(serviceAccountDir, "token"),
+	}, nil
+}
+
+func kubeconfigClient(path string, contextName string) (*kubeClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	var config kubeconfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+
+	var clusterName, userName string
+	found := false
+	for _, context := range config.Contexts {
+		if context.Name == contextName {
+			clusterName, userName, found = context.Context.Cluster, context.Context.User, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found in kubeconfig %s", contextName, path)
+	}
+
+	// Relative paths in a kubeconfig are relative to the kubeconfig itself
+	resolve := func(file string) string {
+		if file == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(filepath.Dir(path), file)
+	}
+
+	client := &kubeClient{}
+	tlsConfig := &tls.Config{}
+
+	for _, cluster := range config.Clusters {
+		if cluster.Name != clusterName {
+			continue
+		}
+
+		client.server = strings.TrimSuffix(cluster.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = cluster.Cluster.InsecureSkipTLSVerify
+
+		ca, err := readInlineOrFile(cluster.Cluster.CertificateAuthorityData, resolve(cluster.Cluster.CertificateAuthority))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate authority: %w", err)
+		}
+		if ca != nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, errors.New("invalid certificate authority in kubeconfig")
+			}
+		}
+	}
+	if client.server == "" {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig %s", clusterName, path)
+	}
+
+	for _, user := range config.Users {
+		if user.Name != userName {
+			continue
+		}
+
+		if user.User.Exec != nil {
+			return nil, fmt.Errorf("user %q uses an exec plugin, which is not supported", userName)
+		}
+
+		client.token = user.User.Token
+		client.tokenFile = resolve(user.User.TokenFile)
+
+		cert, err := readInlineOrFile(user.User.ClientCertificateData, resolve(user.User.ClientCertificate))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		key, err := readInlineOrFile(user.User.ClientKeyData, resolve(user.User.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client key: %w", err)
+		}
+		if cert != nil && key != nil {
+			keyPair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{keyPair}
+		}
+	}
+
+	client.client = newHTTPClient(tlsConfig)
+
+	return client, nil
+}
+
+// newHTTPClient returns a client without an overall timeout, as watches are
+// long running. Requests are limited by their context instead.
+func newHTTPClient(tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}
+}
+
+// readInlineOrFile returns the base64 decoded inline data, or the contents of
+// the file. It returns nil when neither is set.
+func readInlineOrFile(inline string, file string) ([]byte, error) {
+	if inline != "" {
+		return base64.StdEncoding.DecodeString(inline)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+
+	return nil, nil
+}
+
+func (kc *kubeClient) request(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	target := kc.server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	token := kc.token
+	if kc.tokenFile != "" {
+		data, err := os.ReadFile(kc.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := kc.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, errKubeNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s from %s", response.Status, path)
+	}
+
+	return response, nil
+}
+
+// list reads a list of objects into value.
+func (kc *kubeClient) list(ctx context.Context, path string, value any) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	response, err := kc.request(ctx, path, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+// watch waits until an object in the list changes after the resource version,
+// or the API server ends the watch.
+func (kc *kubeClient) watch(ctx context.Context, path string, resourceVersion string) error {
+	query := url.Values{}
+	query.Set("watch", "1")
+	query.Set("resourceVersion", resourceVersion)
+	query.Set("timeoutSeconds", fmt.Sprint(int(watchTimeout.Seconds())))
+
+	response, err := kc.request(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Any event, including an expired resource version, means listing again
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&event); err != nil && ctx.Err() == nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
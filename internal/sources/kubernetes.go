@@ -0,0 +1,337 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+const (
+	KubernetesIngresses  = "ingresses"
+	KubernetesHTTPRoutes = "httproutes"
+)
+
+// kubernetesAPIs maps the supported resources to their API group and version.
+var kubernetesAPIs = map[string]string{
+	KubernetesIngresses:  "/apis/networking.k8s.io/v1",
+	KubernetesHTTPRoutes: "/apis/gateway.networking.k8s.io/v1",
+}
+
+type kubeMetadata struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	Annotations     map[string]string `json:"annotations"`
+	ResourceVersion string            `json:"resourceVersion"`
+}
+
+type ingressList struct {
+	Metadata kubeMetadata `json:"metadata"`
+	Items    []struct {
+		Metadata kubeMetadata `json:"metadata"`
+		Spec     struct {
+			TLS []struct {
+				Hosts []string `json:"hosts"`
+			} `json:"tls"`
+			Rules []struct {
+				Host string `json:"host"`
+				HTTP *struct {
+					Paths []struct {
+						Path string `json:"path"`
+					} `json:"paths"`
+				} `json:"http"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+type httpRouteList struct {
+	Metadata kubeMetadata `json:"metadata"`
+	Items    []struct {
+		Metadata kubeMetadata `json:"metadata"`
+		Spec     struct {
+			Hostnames []string `json:"hostnames"`
+			Rules     []struct {
+				Matches []struct {
+					Path *struct {
+						Type  string `json:"type"`
+						Value string `json:"value"`
+					} `json:"path"`
+				} `json:"matches"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// Kubernetes discovers applications from Ingress and Gateway API HTTPRoute
+// objects. The objects are watched, so changes show up right away.
+type Kubernetes struct {
+	poller
+	config c.KubernetesSourceConfiguration
+	client *kubeClient
+}
+
+func NewKubernetes(config c.KubernetesSourceConfiguration) (*Kubernetes, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if len(config.Resources) == 0 {
+		config.Resources = []string{KubernetesIngresses, KubernetesHTTPRoutes}
+	}
+	for _, resource := range config.Resources {
+		if _, exists := kubernetesAPIs[resource]; !exists {
+			return nil, fmt.Errorf("kubernetes source %s: unknown resource %q, expected ingresses or httproutes", config.Name, resource)
+		}
+	}
+	if config.HTTPRouteScheme == "" {
+		config.HTTPRouteScheme = "https"
+	}
+
+	client, err := newKubeClient(config.Kubeconfig, config.Context)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes source %s: %w", config.Name, err)
+	}
+
+	return &Kubernetes{
+		poller: newPoller("kubernetes", config.Name),
+		config: config,
+		client: client,
+	}, nil
+}
+
+func (k *Kubernetes) Watch(ctx context.Context, onChange func()) error {
+	k.setOnChange(onChange)
+	go k.run(ctx)
+
+	return nil
+}
+
+// run lists the objects and then watches them until something changes, after
+// which they are listed again.
+func (k *Kubernetes) run(ctx context.Context) {
+	for {
+		apps, versions, err := k.fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		k.update(apps, err)
+
+		if err != nil {
+			if !sleep(ctx, retryInterval) {
+				return
+			}
+			continue
+		}
+
+		if err := k.waitForChange(ctx, versions); err != nil {
+			c.Logger.Debug().Err(err).Str("source", k.id).Msg("failed to watch objects")
+			if !sleep(ctx, retryInterval) {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// listPaths returns the API paths to list, per namespace when configured.
+func (k *Kubernetes) listPaths(resource string) []string {
+	api := kubernetesAPIs[resource]
+	if len(k.config.Namespaces) == 0 {
+		return []string{api + "/" + resource}
+	}
+
+	paths := []string{}
+	for _, namespace := range k.config.Namespaces {
+		paths = append(paths, api+"/namespaces/"+namespace+"/"+resource)
+	}
+
+	return paths
+}
+
+// fetch lists all objects and returns their applications and the resource
+// versions to watch from, by path.
+func (k *Kubernetes) fetch(ctx context.Context) ([]m.ContainerInfo, map[string]string, error) {
+	apps := []m.ContainerInfo{}
+	versions := map[string]string{}
+
+	for _, resource := range k.config.Resources {
+		for _, path := range k.listPaths(resource) {
+			var err error
+			switch resource {
+			case KubernetesIngresses:
+				var list ingressList
+				if err = k.client.list(ctx, path, &list); err == nil {
+					apps = append(apps, k.ingressApps(list)...)
+					versions[path] = list.Metadata.ResourceVersion
+				}
+			case KubernetesHTTPRoutes:
+				var list httpRouteList
+				if err = k.client.list(ctx, path, &list); err == nil {
+					apps = append(apps, k.httpRouteApps(list)...)
+					versions[path] = list.Metadata.ResourceVersion
+				}
+			}
+
+			// Clusters without the Gateway API don't know HTTPRoutes
+			if errors.Is(err, errKubeNotFound) {
+				c.Logger.Debug().Str("source", k.id).Str("path", path).Msg("resource not available in cluster")
+				continue
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to list %s: %w", resource, err)
+			}
+		}
+	}
+
+	return apps, versions, nil
+}
+
+// waitForChange watches every listed path and returns as soon as one of the
+// watches ends.
+func (k *Kubernetes) waitForChange(ctx context.Context, versions map[string]string) error {
+	if len(versions) == 0 {
+		sleep(ctx, retryInterval)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, len(versions))
+	for path, version := range versions {
+		go func() {
+			done <- k.client.watch(ctx, path, version)
+		}()
+	}
+
+	return <-done
+}
+
+func (k *Kubernetes) ingressApps(list ingressList) []m.ContainerInfo {
+	apps := []m.ContainerInfo{}
+
+	for _, ingress := range list.Items {
+		tlsHosts := []string{}
+		for _, tls := range ingress.Spec.TLS {
+			tlsHosts = append(tlsHosts, tls.Hosts...)
+		}
+
+		urls := []string{}
+		for _, rule := range ingress.Spec.Rules {
+			scheme := "http"
+			if slices.ContainsFunc(tlsHosts, func(tlsHost string) bool { return hostMatches(tlsHost, rule.Host) }) {
+				scheme = "https"
+			}
+
+			paths := []string{"/"}
+			if rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+				paths = []string{}
+				for _, path := range rule.HTTP.Paths {
+					paths = append(paths, path.Path)
+				}
+			}
+
+			for _, path := range paths {
+				urls = appendUrl(urls, scheme, rule.Host, path)
+			}
+		}
+
+		apps = append(apps, k.objectApps(ingress.Metadata, urls)...)
+	}
+
+	return apps
+}
+
+func (k *Kubernetes) httpRouteApps(list httpRouteList) []m.ContainerInfo {
+	apps := []m.ContainerInfo{}
+
+	for _, route := range list.Items {
+		paths := []string{}
+		for _, rule := range route.Spec.Rules {
+			for _, match := range rule.Matches {
+				if match.Path != nil && match.Path.Type != "RegularExpression" {
+					paths = append(paths, match.Path.Value)
+				}
+			}
+		}
+		if len(paths) == 0 {
+			paths = []string{"/"}
+		}
+
+		urls := []string{}
+		for _, hostname := range route.Spec.Hostnames {
+			for _, path := range paths {
+				urls = appendUrl(urls, k.config.HTTPRouteScheme, hostname, path)
+			}
+		}
+
+		apps = append(apps, k.objectApps(route.Metadata, urls)...)
+	}
+
+	return apps
+}
+
+// objectApps turns an object into an application per URL, unless the
+// homedash.url annotation sets the URL. Objects without a URL are skipped.
+func (k *Kubernetes) objectApps(metadata kubeMetadata, urls []string) []m.ContainerInfo {
+	app, found := appFromLabels(metadata.Annotations)
+	if !found && (!k.config.All || isDisabled(metadata.Annotations)) {
+		return nil
+	}
+
+	if app.Name == "" {
+		app.Name = metadata.Name
+	}
+
+	apps := expandUrls(app, urls)
+	if len(apps) == 0 {
+		c.Logger.Debug().Str("source", k.id).Str("namespace", metadata.Namespace).Str("name", metadata.Name).Msg("object has no host to link to")
+	}
+
+	return apps
+}
+
+// appendUrl adds the URL for the host and path, skipping wildcard hosts and
+// paths that are regular expressions.
+func appendUrl(urls []string, scheme string, host string, path string) []string {
+	if host == "" || strings.Contains(host, "*") || strings.ContainsAny(path, "()[]^$") {
+		return urls
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	url := scheme + "://" + host + path
+	if slices.Contains(urls, url) {
+		return urls
+	}
+
+	return append(urls, url)
+}
+
+// hostMatches compares a host to a pattern like "*.example.com".
+func hostMatches(pattern string, host string) bool {
+	if suffix, found := strings.CutPrefix(pattern, "*."); found {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+
+	return strings.EqualFold(pattern, host)
+}
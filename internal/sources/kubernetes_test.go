@@ -0,0 +1,344 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+const (
+	ingressesPath  = "/apis/networking.k8s.io/v1/ingresses"
+	httpRoutesPath = "/apis/gateway.networking.k8s.io/v1/httproutes"
+)
+
+// fakeKube is a Kubernetes API server that lists and watches fixed objects.
+// Paths without objects return 404, like a cluster without the Gateway API.
+type fakeKube struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	items   map[string]string
+	version int
+	changed chan struct{}
+}
+
+func newFakeKube(t *testing.T, items map[string]string) *fakeKube {
+	kube := &fakeKube{items: items, version: 1, changed: make(chan struct{})}
+	kube.Server = httptest.NewServer(http.HandlerFunc(kube.serve))
+	t.Cleanup(kube.Close)
+
+	return kube
+}
+
+// set replaces the objects at the path and ends the running watches.
+func (kube *fakeKube) set(path string, items string) {
+	kube.mu.Lock()
+	defer kube.mu.Unlock()
+
+	kube.items[path] = items
+	kube.version++
+	close(kube.changed)
+	kube.changed = make(chan struct{})
+}
+
+func (kube *fakeKube) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	kube.mu.Lock()
+	items, exists := kube.items[r.URL.Path]
+	version := strconv.Itoa(kube.version)
+	changed := kube.changed
+	kube.mu.Unlock()
+
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.URL.Query().Get("watch") != "1" {
+		fmt.Fprintf(w, `{"metadata":{"resourceVersion":%q},"items":%s}`, version, items)
+		return
+	}
+
+	// Watches from an older version get the changes since right away
+	if r.URL.Query().Get("resourceVersion") == version {
+		w.(http.Flusher).Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	fmt.Fprint(w, `{"type":"MODIFIED","object":{}}`)
+}
+
+// newTestKubernetes returns a Kubernetes source for the server, using a
+// kubeconfig with a token file relative to it.
+func newTestKubernetes(t *testing.T, server string, config c.KubernetesSourceConfiguration) *Kubernetes {
+	dir := t.TempDir()
+	kubeconfig := `
+current-context: test
+clusters:
+  - name: test
+    cluster:
+      server: ` + server + `
+users:
+  - name: test
+    user:
+      tokenFile: token
+contexts:
+  - name: test
+    context:
+      cluster: test
+      user: test
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("test-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Kubeconfig = filepath.Join(dir, "config")
+	k, err := NewKubernetes(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestKubernetesWatchesObjects(t *testing.T) {
+	jellyfin := `{"metadata":{"name":"jellyfin","annotations":{"homedash.name":"Jellyfin"}},"spec":{"rules":[{"host":"jellyfin.example.com"}]}}`
+	grafana := `{"metadata":{"name":"grafana","annotations":{"homedash.icon":"grafana"}},"spec":{"rules":[{"host":"grafana.example.com"}]}}`
+
+	kube := newFakeKube(t, map[string]string{ingressesPath: "[" + jellyfin + "]"})
+	k := newTestKubernetes(t, kube.URL, c.KubernetesSourceConfiguration{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	changes := make(chan struct{}, 10)
+	if err := k.Watch(ctx, func() { changes <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForChange(t, changes)
+	if urls := appUrls(k.List()); !slices.Equal(urls, []string{"Jellyfin http://jellyfin.example.com/"}) {
+		t.Errorf("unexpected applications %v", urls)
+	}
+	if err := k.Health(); err != nil {
+		t.Errorf("expected missing HTTPRoutes to be ignored, got %v", err)
+	}
+
+	kube.set(ingressesPath, "["+jellyfin+","+grafana+"]")
+
+	waitForChange(t, changes)
+	expected := []string{"Jellyfin http://jellyfin.example.com/", "grafana http://grafana.example.com/"}
+	if urls := appUrls(k.List()); !slices.Equal(urls, expected) {
+		t.Errorf("expected %v after the change, got %v", expected, urls)
+	}
+}
+
+func TestKubernetesObjectApps(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     c.KubernetesSourceConfiguration
+		ingresses  string
+		httpRoutes string
+		expected   []string
+	}{
+		{
+			name:      "ingress with TLS and paths",
+			ingresses: `[{"metadata":{"name":"media","annotations":{"homedash.name":"Media"}},"spec":{"tls":[{"hosts":["*.example.com"]}],"rules":[{"host":"media.example.com","http":{"paths":[{"path":"/movies"},{"path":"/music"}]}},{"host":"media.example.org"}]}}]`,
+			expected: []string{
+				"Media (media.example.com/movies) https://media.example.com/movies",
+				"Media (media.example.com/music) https://media.example.com/music",
+				"Media (media.example.org) http://media.example.org/",
+			},
+		},
+		{
+			name:      "annotated URL",
+			ingresses: `[{"metadata":{"name":"wiki","annotations":{"homedash.url":"https://wiki.example.com/start"}},"spec":{"rules":[{"host":"wiki.internal"},{"host":"docs.internal"}]}}]`,
+			expected:  []string{"wiki https://wiki.example.com/start"},
+		},
+		{
+			name:      "without annotations",
+			ingresses: `[{"metadata":{"name":"api"},"spec":{"rules":[{"host":"api.example.com"}]}}]`,
+			expected:  []string{},
+		},
+		{
+			name:      "all objects",
+			config:    c.KubernetesSourceConfiguration{All: true},
+			ingresses: `[{"metadata":{"name":"api"},"spec":{"rules":[{"host":"api.example.com"}]}},{"metadata":{"name":"hidden","annotations":{"homedash.enable":"false"}},"spec":{"rules":[{"host":"hidden.example.com"}]}}]`,
+			expected:  []string{"api http://api.example.com/"},
+		},
+		{
+			name:      "wildcard host and regular expression path",
+			config:    c.KubernetesSourceConfiguration{All: true},
+			ingresses: `[{"metadata":{"name":"catchall"},"spec":{"rules":[{"host":"*.example.com"},{"host":"app.example.com","http":{"paths":[{"path":"/(api|ui)"}]}}]}}]`,
+			expected:  []string{},
+		},
+		{
+			name:       "httproute",
+			config:     c.KubernetesSourceConfiguration{Resources: []string{KubernetesHTTPRoutes}},
+			httpRoutes: `[{"metadata":{"name":"grafana","annotations":{"homedash.group":"Monitoring"}},"spec":{"hostnames":["grafana.example.com"],"rules":[{"matches":[{"path":{"type":"PathPrefix","value":"/"}},{"path":{"type":"RegularExpression","value":"/d/.*"}}]}]}}]`,
+			expected:   []string{"grafana https://grafana.example.com/"},
+		},
+		{
+			name:       "httproute scheme",
+			config:     c.KubernetesSourceConfiguration{Resources: []string{KubernetesHTTPRoutes}, HTTPRouteScheme: "http"},
+			httpRoutes: `[{"metadata":{"name":"grafana","annotations":{"homedash.enable":"true"}},"spec":{"hostnames":["grafana.lan"]}}]`,
+			expected:   []string{"grafana http://grafana.lan/"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items := map[string]string{}
+			if test.ingresses != "" {
+				items[ingressesPath] = test.ingresses
+			}
+			if test.httpRoutes != "" {
+				items[httpRoutesPath] = test.httpRoutes
+			}
+			kube := newFakeKube(t, items)
+			k := newTestKubernetes(t, kube.URL, test.config)
+
+			apps, _, err := k.fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if urls := appUrls(apps); !slices.Equal(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
+
+func TestKubernetesNamespaces(t *testing.T) {
+	kube := newFakeKube(t, map[string]string{
+		"/apis/networking.k8s.io/v1/namespaces/media/ingresses": `[{"metadata":{"name":"jellyfin","namespace":"media","annotations":{"homedash.name":"Jellyfin"}},"spec":{"rules":[{"host":"jellyfin.example.com"}]}}]`,
+	})
+	k := newTestKubernetes(t, kube.URL, c.KubernetesSourceConfiguration{
+		Namespaces: []string{"media"},
+		Resources:  []string{KubernetesIngresses},
+	})
+
+	apps, versions, err := k.fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 1 || apps[0].Name != "Jellyfin" {
+		t.Errorf("expected Jellyfin from the media namespace, got %v", appUrls(apps))
+	}
+	if versions["/apis/networking.k8s.io/v1/namespaces/media/ingresses"] != "1" {
+		t.Errorf("expected to watch the media namespace from version 1, got %v", versions)
+	}
+}
+
+func TestKubernetesUnauthorized(t *testing.T) {
+	kube := newFakeKube(t, map[string]string{ingressesPath: "[]"})
+	k := newTestKubernetes(t, kube.URL, c.KubernetesSourceConfiguration{})
+	k.client.tokenFile = ""
+	k.client.token = "wrong"
+
+	if _, _, err := k.fetch(context.Background()); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
+
+func TestKubeconfigClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+		context    string
+		err        bool
+	}{
+		{
+			name: "inline token",
+			kubeconfig: `
+current-context: home
+clusters: [{name: k3s, cluster: {server: "https://k3s.lan:6443/"}}]
+users: [{name: admin, user: {token: secret}}]
+contexts: [{name: home, context: {cluster: k3s, user: admin}}]
+`,
+		},
+		{
+			name: "unknown context",
+			kubeconfig: `
+current-context: home
+clusters: [{name: k3s, cluster: {server: "https://k3s.lan:6443"}}]
+contexts: [{name: home, context: {cluster: k3s, user: admin}}]
+`,
+			context: "work",
+			err:     true,
+		},
+		{
+			name: "unknown cluster",
+			kubeconfig: `
+current-context: home
+contexts: [{name: home, context: {cluster: k3s, user: admin}}]
+`,
+			err: true,
+		},
+		{
+			name: "exec plugin",
+			kubeconfig: `
+current-context: home
+clusters: [{name: k3s, cluster: {server: "https://k3s.lan:6443"}}]
+users: [{name: admin, user: {exec: {command: kubelogin}}}]
+contexts: [{name: home, context: {cluster: k3s, user: admin}}]
+`,
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(test.kubeconfig), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			client, err := kubeconfigClient(path, test.context)
+			if test.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client.server != "https://k3s.lan:6443" || client.token != "secret" {
+				t.Errorf("unexpected client for %s with token %q", client.server, client.token)
+			}
+		})
+	}
+}
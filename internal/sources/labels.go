@@ -0,0 +1,104 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"strconv"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// labelPrefix is the prefix of the labels, annotations, tags or meta keys
+// describing an application, as used by the sidecar.
+const labelPrefix = "homedash."
+
+// appFromLabels builds an application from homedash.* labels. It returns
+// false when there are no such labels or homedash.enable is false.
+func appFromLabels(labels map[string]string) (m.ContainerInfo, bool) {
+	app := m.ContainerInfo{}
+	found := false
+
+	for key, value := range labels {
+		field, isHomeDash := strings.CutPrefix(key, labelPrefix)
+		if !isHomeDash {
+			continue
+		}
+		found = true
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "enable":
+			if isDisabled(labels) {
+				return app, false
+			}
+		case "id":
+			app.ID = value
+		case "name":
+			app.Name = value
+		case "url":
+			app.Url = value
+		case "icon":
+			app.Icon = value
+		case "comment":
+			app.Comment = value
+		case "group":
+			app.Group = value
+		case "tags":
+			app.Tags = splitList(value)
+		case "weight":
+			weight, err := strconv.Atoi(value)
+			if err != nil {
+				c.Logger.Debug().Str("label", key).Str("value", value).Msg("ignoring invalid weight")
+				continue
+			}
+			app.Weight = weight
+		case "description":
+			app.Description = value
+		case "target":
+			app.Target = value
+		case "internalurl":
+			app.InternalUrl = value
+		case "externalurl":
+			app.ExternalUrl = value
+		default:
+			if metadataKey, isMetadata := strings.CutPrefix(field, "metadata."); isMetadata && metadataKey != "" {
+				if app.Metadata == nil {
+					app.Metadata = map[string]string{}
+				}
+				app.Metadata[metadataKey] = value
+			}
+		}
+	}
+
+	return app, found
+}
+
+// isDisabled reports whether homedash.enable is set to false.
+func isDisabled(labels map[string]string) bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(labels[labelPrefix+"enable"]))
+
+	return err == nil && !enabled
+}
+
+// splitList splits a comma separated value, dropping empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,185 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/mdns"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+var mdnsServicePattern = regexp.MustCompile(`^_[a-z0-9-]+\._(tcp|udp)$`)
+
+// MDNS discovers services advertised over mDNS on the local network, like
+// printers, NAS boxes and ESPHome devices.
+type MDNS struct {
+	poller
+	config  c.MDNSSourceConfiguration
+	browser *mdns.Browser
+}
+
+func NewMDNS(config c.MDNSSourceConfiguration) (*MDNS, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if len(config.Services) == 0 {
+		config.Services = []string{"_http._tcp"}
+	}
+	if config.Interval <= 0 {
+		config.Interval = 60
+	}
+
+	for i, service := range config.Services {
+		config.Services[i] = strings.ToLower(strings.TrimSuffix(service, ".local"))
+		if !mdnsServicePattern.MatchString(config.Services[i]) {
+			return nil, fmt.Errorf("mdns source %s: invalid service type %q, expected something like _http._tcp", config.Name, service)
+		}
+	}
+
+	for _, pattern := range append(config.Allow, config.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("mdns source %s: invalid pattern %q: %w", config.Name, pattern, err)
+		}
+	}
+
+	return &MDNS{
+		poller:  newPoller("mdns", config.Name),
+		config:  config,
+		browser: mdns.NewBrowser(config.Services, config.Interface),
+	}, nil
+}
+
+func (d *MDNS) Watch(ctx context.Context, onChange func()) error {
+	d.setOnChange(onChange)
+	go d.run(ctx)
+
+	return nil
+}
+
+// run browses the network, starting over when the connection fails.
+func (d *MDNS) run(ctx context.Context) {
+	for {
+		err := d.browser.Run(ctx, time.Duration(d.config.Interval)*time.Second, d.refresh)
+		if ctx.Err() != nil {
+			return
+		}
+		d.update(nil, err)
+
+		if !sleep(ctx, retryInterval) {
+			return
+		}
+	}
+}
+
+// refresh builds the applications from the instances the browser knows.
+func (d *MDNS) refresh() {
+	apps := []m.ContainerInfo{}
+
+	for _, instance := range d.browser.Instances() {
+		if app, ok := d.instanceApp(instance); ok {
+			apps = append(apps, app)
+		}
+	}
+
+	d.update(apps, nil)
+}
+
+// instanceApp describes an instance as an application. It reports false for
+// instances that are filtered out or disabled.
+func (d *MDNS) instanceApp(instance mdns.Instance) (m.ContainerInfo, bool) {
+	// Any host on the network can announce a blank name
+	if strings.TrimSpace(instance.Name) == "" || !d.includes(instance.Name) {
+		return m.ContainerInfo{}, false
+	}
+
+	// TXT records can describe the application like labels do
+	labels := map[string]string{}
+	for key, value := range instance.Text {
+		if strings.HasPrefix(key, labelPrefix) {
+			labels[key] = value
+		}
+	}
+	if isDisabled(labels) {
+		return m.ContainerInfo{}, false
+	}
+	app, _ := appFromLabels(labels)
+
+	if app.Name == "" {
+		app.Name = instance.Name
+	}
+	if app.Icon == "" {
+		host, _, _ := strings.Cut(instance.Host, ".")
+		service, _, _ := strings.Cut(instance.Service, ".")
+		app.Icon = c.GuessIcon(instance.Name, strings.Fields(instance.Name)[0], host, strings.TrimPrefix(service, "_"))
+	}
+	if app.Url == "" {
+		app.Url = d.instanceUrl(instance)
+	}
+
+	return app, true
+}
+
+// includes reports whether the instance name passes the allow and deny
+// patterns. Names are compared in lower case.
+func (d *MDNS) includes(name string) bool {
+	name = strings.ToLower(name)
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	if matches(d.config.Deny) {
+		return false
+	}
+
+	return len(d.config.Allow) == 0 || matches(d.config.Allow)
+}
+
+// instanceUrl returns the URL of an instance, using its host name or, when
+// configured, its address. The path comes from the path TXT entry that
+// DNS-SD defines for web services.
+func (d *MDNS) instanceUrl(instance mdns.Instance) string {
+	scheme := "http"
+	if instance.Service == "_https._tcp" {
+		scheme = "https"
+	}
+
+	host := instance.Host
+	if d.config.Addresses && len(instance.IPs) > 0 {
+		host = instance.IPs[0].String()
+	}
+	if (scheme == "http" && instance.Port != 80) || (scheme == "https" && instance.Port != 443) {
+		host = net.JoinHostPort(host, strconv.Itoa(instance.Port))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	urlPath := instance.Text["path"]
+	if !strings.HasPrefix(urlPath, "/") {
+		urlPath = "/"
+	}
+
+	return scheme + "://" + host + urlPath
+}
@@ -0,0 +1,100 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/mdns"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+func TestMDNSInstanceApp(t *testing.T) {
+	withIcons(t, c.IconIndex{"octoprint": "octoprint.svg", "nas": "nas.svg"})
+
+	tests := []struct {
+		name     string
+		config   c.MDNSSourceConfiguration
+		instance mdns.Instance
+		expected *m.ContainerInfo
+	}{
+		{
+			name:     "name and port",
+			instance: mdns.Instance{Name: "OctoPrint on Ender", Service: "_http._tcp", Host: "ender.local", Port: 5000, Text: map[string]string{"path": "/printer"}},
+			expected: &m.ContainerInfo{Name: "OctoPrint on Ender", Icon: "octoprint", Url: "http://ender.local:5000/printer"},
+		},
+		{
+			name:     "icon from the host",
+			instance: mdns.Instance{Name: "Storage", Service: "_https._tcp", Host: "nas.local", Port: 443, Text: map[string]string{}},
+			expected: &m.ContainerInfo{Name: "Storage", Icon: "nas", Url: "https://nas.local/"},
+		},
+		{
+			name:     "address",
+			config:   c.MDNSSourceConfiguration{Addresses: true},
+			instance: mdns.Instance{Name: "Storage", Service: "_http._tcp", Host: "nas.local", Port: 80, IPs: []net.IP{net.ParseIP("fd00::10")}, Text: map[string]string{}},
+			expected: &m.ContainerInfo{Name: "Storage", Icon: "nas", Url: "http://[fd00::10]/"},
+		},
+		{
+			name:     "TXT labels",
+			instance: mdns.Instance{Name: "Storage", Service: "_http._tcp", Host: "nas.local", Port: 80, Text: map[string]string{"homedash.name": "NAS", "homedash.url": "https://nas.example.com"}},
+			expected: &m.ContainerInfo{Name: "NAS", Icon: "nas", Url: "https://nas.example.com"},
+		},
+		{
+			name:     "disabled",
+			instance: mdns.Instance{Name: "Storage", Service: "_http._tcp", Host: "nas.local", Port: 80, Text: map[string]string{"homedash.enable": "false"}},
+		},
+		{
+			name:     "denied",
+			config:   c.MDNSSourceConfiguration{Deny: []string{"stor*"}},
+			instance: mdns.Instance{Name: "Storage", Service: "_http._tcp", Host: "nas.local", Port: 80, Text: map[string]string{}},
+		},
+		{
+			name:     "not allowed",
+			config:   c.MDNSSourceConfiguration{Allow: []string{"octo*"}},
+			instance: mdns.Instance{Name: "Storage", Service: "_http._tcp", Host: "nas.local", Port: 80, Text: map[string]string{}},
+		},
+		{
+			name:     "empty name",
+			instance: mdns.Instance{Name: "", Service: "_http._tcp", Host: "nas.local", Port: 80, Text: map[string]string{}},
+		},
+		{
+			name:     "blank name",
+			instance: mdns.Instance{Name: " \t ", Service: "_http._tcp", Host: "nas.local", Port: 80, Text: map[string]string{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := NewMDNS(test.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			app, ok := source.instanceApp(test.instance)
+			if test.expected == nil {
+				if ok {
+					t.Errorf("expected the instance to be skipped, got %+v", app)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected an application")
+			}
+			if !reflect.DeepEqual(app, *test.expected) {
+				t.Errorf("expected %+v, got %+v", *test.expected, app)
+			}
+		})
+	}
+}
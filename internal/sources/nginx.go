@@ -0,0 +1,192 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// nginxServer collects what is needed from a server block.
+type nginxServer struct {
+	names   []string
+	listens [][]string
+	labels  map[string]string
+}
+
+// NewNginx discovers the server blocks in nginx configs.
+func NewNginx(config c.ConfigFileSourceConfiguration) (*fileSource, error) {
+	return newFileSource("nginx", config.Name, config.Paths, parseNginxFile)
+}
+
+func parseNginxFile(path string) ([]m.ContainerInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := parseNginxServers(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	apps := []m.ContainerInfo{}
+	for _, server := range servers {
+		apps = append(apps, siteApps(server.labels, server.urls())...)
+	}
+
+	return apps, nil
+}
+
+// parseNginxServers reads the server_name and listen directives of server
+// blocks. Comments like "# homedash.icon jellyfin" inside a server block
+// describe the application.
+func parseNginxServers(config string) ([]nginxServer, error) {
+	servers := []nginxServer{}
+
+	// blocks holds the name of every open block, server marks the depth of the
+	// current server block
+	blocks := []string{}
+	serverDepth := -1
+	var server *nginxServer
+
+	tokens := []string{}
+	token := strings.Builder{}
+	flush := func() {
+		if token.Len() > 0 {
+			tokens = append(tokens, token.String())
+			token.Reset()
+		}
+	}
+
+	for i := 0; i < len(config); i++ {
+		char := config[i]
+
+		switch {
+		case char == '#':
+			flush()
+			end := strings.IndexByte(config[i:], '\n')
+			if end < 0 {
+				end = len(config) - i
+			}
+			if server != nil && len(blocks) == serverDepth {
+				commentLabel(config[i:i+end], server.labels)
+			}
+			i += end
+		case char == '"' || char == '\'':
+			end := strings.IndexByte(config[i+1:], char)
+			if end < 0 {
+				end = len(config) - i - 1
+			}
+			token.WriteString(config[i+1 : i+1+end])
+			i += end + 1
+		case char == '{':
+			flush()
+			name := ""
+			if len(tokens) > 0 {
+				name = tokens[0]
+			}
+			blocks = append(blocks, name)
+			if name == "server" && server == nil {
+				server = &nginxServer{labels: map[string]string{}}
+				serverDepth = len(blocks)
+			}
+			tokens = nil
+		case char == '}':
+			flush()
+			if server != nil && len(blocks) == serverDepth {
+				servers = append(servers, *server)
+				server, serverDepth = nil, -1
+			}
+			if len(blocks) == 0 {
+				return nil, errors.New("unexpected }")
+			}
+			blocks = blocks[:len(blocks)-1]
+			tokens = nil
+		case char == ';':
+			flush()
+			if server != nil && len(blocks) == serverDepth && len(tokens) > 1 {
+				switch tokens[0] {
+				case "server_name":
+					server.names = append(server.names, tokens[1:]...)
+				case "listen":
+					server.listens = append(server.listens, tokens[1:])
+				}
+			}
+			tokens = nil
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r':
+			flush()
+		default:
+			token.WriteByte(char)
+		}
+	}
+
+	if len(blocks) > 0 {
+		return nil, errors.New("unexpected end of file, missing }")
+	}
+
+	return servers, nil
+}
+
+// urls returns a URL for every usable server name. Servers listening with ssl
+// use https, ports other than 80 and 443 are kept.
+func (s nginxServer) urls() []string {
+	scheme := "http"
+	port := ""
+
+	for _, listen := range s.listens {
+		address := listen[0]
+		listenPort := address
+		if _, p, err := net.SplitHostPort(address); err == nil {
+			listenPort = p
+		}
+		if strings.Contains(listenPort, ".") || strings.HasPrefix(listenPort, "unix:") {
+			continue
+		}
+
+		ssl := slices.Contains(listen[1:], "ssl") || slices.Contains(listen[1:], "quic")
+		if ssl || (scheme == "http" && port == "") {
+			if ssl {
+				scheme = "https"
+			}
+			port = listenPort
+		}
+		if ssl {
+			break
+		}
+	}
+
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+
+	urls := []string{}
+	for _, name := range s.names {
+		// Catch-all, regular expression and wildcard names can't be linked to
+		if name == "_" || name == "" || strings.HasPrefix(name, "~") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		host := name
+		if port != "" {
+			host = net.JoinHostPort(name, port)
+		}
+		urls = appendUrl(urls, scheme, host, "/")
+	}
+
+	return urls
+}
@@ -0,0 +1,87 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"reflect"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+func TestParseNginxFile(t *testing.T) {
+	withIcons(t, c.IconIndex{})
+
+	apps, err := parseNginxFile("testdata/nginx/nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Catch-all, regular expression and wildcard names and disabled servers
+	// are left out, comments outside server blocks don't apply
+	expected := []string{
+		"jellyfin https://jellyfin.example.com/ icon=jellyfin group=Media",
+		"git (git.example.com:8080) http://git.example.com:8080/ icon= group=",
+		"git (code.example.com:8080) http://code.example.com:8080/ icon= group=",
+		"grafana https://grafana.example.com:8443/ icon= group=",
+		"printer http://printer.lan/ icon= group=",
+	}
+	if labels := appLabels(apps); !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected %q, got %q", expected, labels)
+	}
+	if apps[1].Description != "Source code" {
+		t.Errorf("expected the description from the comment, got %q", apps[1].Description)
+	}
+}
+
+func TestParseNginxServersErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		nginx    string
+		expected string
+	}{
+		{name: "unexpected brace", nginx: "server { listen 80; } }", expected: "unexpected }"},
+		{name: "missing brace", nginx: "http { server { listen 80; }", expected: "unexpected end of file, missing }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseNginxServers(test.nginx); err == nil || err.Error() != test.expected {
+				t.Errorf("expected error %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestNginxServerUrls(t *testing.T) {
+	tests := []struct {
+		name     string
+		listens  [][]string
+		expected []string
+	}{
+		{name: "default", expected: []string{"http://example.com/"}},
+		{name: "port 80", listens: [][]string{{"80"}}, expected: []string{"http://example.com/"}},
+		{name: "ssl", listens: [][]string{{"80"}, {"443", "ssl"}}, expected: []string{"https://example.com/"}},
+		{name: "ssl on another port", listens: [][]string{{"[::]:8443", "ssl"}}, expected: []string{"https://example.com:8443/"}},
+		{name: "http on another port", listens: [][]string{{"0.0.0.0:8080"}}, expected: []string{"http://example.com:8080/"}},
+		{name: "address only", listens: [][]string{{"127.0.0.1"}, {"8080"}}, expected: []string{"http://example.com:8080/"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := nginxServer{names: []string{"example.com", "_"}, listens: test.listens}
+			if urls := server.urls(); !reflect.DeepEqual(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
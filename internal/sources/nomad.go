@@ -0,0 +1,179 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// nomadIndexHeader holds the index of a Nomad response, for blocking queries.
+const nomadIndexHeader = "X-Nomad-Index"
+
+type nomadNamespaceServices struct {
+	Namespace string `json:"Namespace"`
+	Services  []struct {
+		ServiceName string `json:"ServiceName"`
+	} `json:"Services"`
+}
+
+type nomadRegistration struct {
+	AllocID string   `json:"AllocID"`
+	Tags    []string `json:"Tags"`
+	Address string   `json:"Address"`
+	Port    int      `json:"Port"`
+}
+
+type nomadCheckResult struct {
+	Service string `json:"Service"`
+	Status  string `json:"Status"`
+}
+
+// Nomad discovers applications from services registered with Nomad's own
+// service discovery. The status of an application follows the checks of its
+// allocations.
+type Nomad struct {
+	poller
+	config  c.NomadSourceConfiguration
+	indexes []uint64
+}
+
+func NewNomad(config c.NomadSourceConfiguration) (*Nomad, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Url == "" {
+		return nil, fmt.Errorf("nomad source %s: url is required", config.Name)
+	}
+	if config.Namespace == "" {
+		config.Namespace = "*"
+	}
+	if config.Interval <= 0 {
+		config.Interval = 60
+	}
+
+	return &Nomad{
+		poller:  newPoller("nomad", config.Name),
+		config:  config,
+		indexes: make([]uint64, 1),
+	}, nil
+}
+
+func (n *Nomad) Watch(ctx context.Context, onChange func()) error {
+	n.setOnChange(onChange)
+	go n.run(ctx)
+
+	return nil
+}
+
+// run fetches the services whenever a blocking query reports a change. Check
+// results are not covered by the query, so they are refreshed every interval.
+func (n *Nomad) run(ctx context.Context) {
+	for {
+		apps, err := n.fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		n.update(apps, err)
+
+		if err == nil {
+			err = waitForAny(ctx, []string{n.endpoint("/v1/services", n.config.Namespace)},
+				n.indexes, time.Duration(n.config.Interval)*time.Second, nomadIndexHeader, n.authorize)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.Logger.Debug().Err(err).Str("source", n.id).Msg("waiting before fetching services again")
+			if !sleep(ctx, retryInterval) {
+				return
+			}
+		}
+	}
+}
+
+func (n *Nomad) fetch(ctx context.Context) ([]m.ContainerInfo, error) {
+	namespaces := []nomadNamespaceServices{}
+	if _, err := getJSON(ctx, httpClient, n.endpoint("/v1/services", n.config.Namespace), n.authorize, &namespaces); err != nil {
+		return nil, err
+	}
+
+	// Allocations can run several services, only fetch their checks once
+	checks := map[string]map[string]nomadCheckResult{}
+
+	apps := []m.ContainerInfo{}
+	for _, namespace := range namespaces {
+		for _, service := range namespace.Services {
+			registrations := []nomadRegistration{}
+			if _, err := getJSON(ctx, httpClient, n.endpoint("/v1/service/"+url.PathEscape(service.ServiceName), namespace.Namespace), n.authorize, &registrations); err != nil {
+				return nil, err
+			}
+
+			instances := []catalogInstance{}
+			for _, registration := range registrations {
+				if _, fetched := checks[registration.AllocID]; !fetched && registration.AllocID != "" {
+					checks[registration.AllocID] = n.getChecks(ctx, registration.AllocID, namespace.Namespace)
+				}
+
+				statuses := []string{}
+				for _, check := range checks[registration.AllocID] {
+					if check.Service == service.ServiceName {
+						statuses = append(statuses, checkStatus(check.Status))
+					}
+				}
+
+				instances = append(instances, catalogInstance{
+					Labels:  tagLabels(registration.Tags),
+					Address: registration.Address,
+					Port:    registration.Port,
+					Status:  worstStatus(statuses),
+				})
+			}
+
+			if serviceApps, ok := catalogApp(service.ServiceName, instances, n.config.All); ok {
+				apps = append(apps, serviceApps...)
+			}
+		}
+	}
+
+	return apps, nil
+}
+
+// getChecks returns the latest check results of an allocation. Failing to get
+// them only means the services have no status.
+func (n *Nomad) getChecks(ctx context.Context, allocID string, namespace string) map[string]nomadCheckResult {
+	results := map[string]nomadCheckResult{}
+	if _, err := getJSON(ctx, httpClient, n.endpoint("/v1/client/allocation/"+url.PathEscape(allocID)+"/checks", namespace), n.authorize, &results); err != nil {
+		c.Logger.Debug().Err(err).Str("source", n.id).Str("allocation", allocID).Msg("failed to get check results")
+		return nil
+	}
+
+	return results
+}
+
+// endpoint returns the URL of an API path in the namespace.
+func (n *Nomad) endpoint(path string, namespace string) string {
+	return strings.TrimSuffix(n.config.Url, "/") + path + "?namespace=" + url.QueryEscape(namespace)
+}
+
+func (n *Nomad) authorize(request *http.Request) {
+	if n.config.Token != "" {
+		request.Header.Set("X-Nomad-Token", n.config.Token)
+	}
+}
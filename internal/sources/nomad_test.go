@@ -0,0 +1,136 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// newNomadStandIn serves Jellyfin on two allocations in the default namespace
+// and Plex in the media namespace. Only some allocations have check results.
+func newNomadStandIn(t *testing.T) *catalogStandIn {
+	return newCatalogStandIn(t, "X-Nomad-Token", nomadIndexHeader, map[string]string{
+		"/v1/services": `[
+			{"Namespace": "default", "Services": [{"ServiceName": "jellyfin"}, {"ServiceName": "whoami"}]},
+			{"Namespace": "media", "Services": [{"ServiceName": "plex"}]}
+		]`,
+		"/v1/service/jellyfin": `[
+			{"AllocID": "a1", "Tags": ["homedash.name=Jellyfin"], "Address": "10.0.0.2", "Port": 8096},
+			{"AllocID": "a2", "Tags": ["homedash.name=Jellyfin"], "Address": "10.0.0.3", "Port": 8096}
+		]`,
+		"/v1/service/whoami": `[
+			{"AllocID": "a1", "Tags": [], "Address": "10.0.0.2", "Port": 8080}
+		]`,
+		"/v1/service/plex": `[
+			{"AllocID": "a3", "Tags": ["homedash.url=https://plex.example.com", "homedash.icon=plex"], "Address": "10.0.0.4", "Port": 32400}
+		]`,
+		"/v1/client/allocation/a1/checks": `{
+			"c1": {"Service": "jellyfin", "Status": "success"},
+			"c2": {"Service": "whoami", "Status": "failure"}
+		}`,
+		"/v1/client/allocation/a3/checks": `{
+			"c3": {"Service": "plex", "Status": "failure"}
+		}`,
+	})
+}
+
+func TestNomadServices(t *testing.T) {
+	standIn := newNomadStandIn(t)
+
+	tests := []struct {
+		name     string
+		config   c.NomadSourceConfiguration
+		expected []string
+	}{
+		{
+			name: "labeled services",
+			expected: []string{
+				"Jellyfin (10.0.0.2:8096) http://10.0.0.2:8096 up",
+				"Jellyfin (10.0.0.3:8096) http://10.0.0.3:8096 ",
+				"plex https://plex.example.com down",
+			},
+		},
+		{
+			name:   "all services",
+			config: c.NomadSourceConfiguration{All: true},
+			expected: []string{
+				"Jellyfin (10.0.0.2:8096) http://10.0.0.2:8096 up",
+				"Jellyfin (10.0.0.3:8096) http://10.0.0.3:8096 ",
+				"whoami http://10.0.0.2:8080 down",
+				"plex https://plex.example.com down",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Url = standIn.URL
+			test.config.Token = "test-token"
+			nomad, err := NewNomad(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			apps, err := nomad.fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statuses := appStatuses(apps); !slices.Equal(statuses, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, statuses)
+			}
+		})
+	}
+
+	if query, _ := url.ParseQuery(standIn.query("/v1/service/plex")); query.Get("namespace") != "media" {
+		t.Errorf("expected Plex to be read from its namespace, got %v", query)
+	}
+	if query, _ := url.ParseQuery(standIn.query("/v1/services")); query.Get("namespace") != "*" {
+		t.Errorf("expected services from all namespaces, got %v", query)
+	}
+}
+
+func TestNomadBlockingQueries(t *testing.T) {
+	standIn := newNomadStandIn(t)
+
+	nomad, err := NewNomad(c.NomadSourceConfiguration{Url: standIn.URL, Token: "test-token", Namespace: "default", Interval: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	changes := make(chan struct{}, 10)
+	if err := nomad.Watch(ctx, func() { changes <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, changes)
+
+	standIn.set("/v1/service/jellyfin", `[{"AllocID": "a1", "Tags": ["homedash.name=Jellyfin"], "Address": "10.0.0.2", "Port": 8096}]`)
+	standIn.set("/v1/services", `[{"Namespace": "default", "Services": [{"ServiceName": "jellyfin"}]}]`)
+
+	waitForChange(t, changes)
+	expected := []string{"Jellyfin http://10.0.0.2:8096 up"}
+	if statuses := appStatuses(nomad.List()); !slices.Equal(statuses, expected) {
+		t.Errorf("expected %v, got %v", expected, statuses)
+	}
+
+	query, _ := url.ParseQuery(standIn.lastBlockingQuery("/v1/services"))
+	if query.Get("index") == "" || query.Get("wait") != "30s" || query.Get("namespace") != "default" {
+		t.Errorf("expected a blocking query in the default namespace, got %v", query)
+	}
+}
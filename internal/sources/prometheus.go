@@ -0,0 +1,212 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// targetGroup is a group of targets as used by Prometheus file_sd and HTTP SD.
+type targetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+// targetsResponse is the part of the response of the targets API of a
+// Prometheus server that describes the targets it scrapes.
+type targetsResponse struct {
+	Data struct {
+		ActiveTargets []struct {
+			Labels    map[string]string `json:"labels"`
+			ScrapeUrl string            `json:"scrapeUrl"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// Prometheus discovers applications from an HTTP SD endpoint or from the
+// targets API of a Prometheus server.
+type Prometheus struct {
+	poller
+	config c.PrometheusSourceConfiguration
+}
+
+// NewPrometheus returns a source for the HTTP SD endpoint or targets API at
+// the url.
+func NewPrometheus(config c.PrometheusSourceConfiguration) (*Prometheus, error) {
+	config, err := prometheusDefaults(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Url == "" {
+		return nil, fmt.Errorf("prometheus source %s: url is required", config.Name)
+	}
+
+	return &Prometheus{
+		poller: newPoller("prometheus", config.Name),
+		config: config,
+	}, nil
+}
+
+// NewPrometheusFiles returns a source for the file_sd files in the paths.
+func NewPrometheusFiles(config c.PrometheusSourceConfiguration) (*fileSource, error) {
+	config, err := prometheusDefaults(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Paths) == 0 {
+		return nil, fmt.Errorf("prometheus source %s: paths are required", config.Name)
+	}
+
+	return newFileSource("prometheus", config.Name, config.Paths, func(path string) ([]m.ContainerInfo, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// JSON is valid YAML, so one parser reads both formats
+		groups := []targetGroup{}
+		if err := yaml.Unmarshal(data, &groups); err != nil {
+			return nil, err
+		}
+
+		return targetGroupApps(groups, config.All), nil
+	})
+}
+
+func prometheusDefaults(config c.PrometheusSourceConfiguration) (c.PrometheusSourceConfiguration, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if (len(config.Paths) == 0) == (config.Url == "") {
+		return config, fmt.Errorf("prometheus source %s: either paths or url is required", config.Name)
+	}
+	if config.Interval <= 0 {
+		config.Interval = 60
+	}
+
+	return config, nil
+}
+
+func (p *Prometheus) Watch(ctx context.Context, onChange func()) error {
+	p.setOnChange(onChange)
+	go p.poll(ctx, time.Duration(p.config.Interval)*time.Second, p.fetch)
+
+	return nil
+}
+
+func (p *Prometheus) fetch(ctx context.Context) ([]m.ContainerInfo, error) {
+	var response json.RawMessage
+	if _, err := getJSON(ctx, httpClient, p.config.Url, p.authorize, &response); err != nil {
+		return nil, err
+	}
+
+	// HTTP SD returns a list of target groups, the targets API an object
+	groups := []targetGroup{}
+	if bytes.HasPrefix(bytes.TrimSpace(response), []byte("{")) {
+		targets := targetsResponse{}
+		if err := json.Unmarshal(response, &targets); err != nil {
+			return nil, fmt.Errorf("invalid targets: %w", err)
+		}
+		groups = targets.groups()
+	} else if err := json.Unmarshal(response, &groups); err != nil {
+		return nil, fmt.Errorf("invalid target groups: %w", err)
+	}
+
+	return targetGroupApps(groups, p.config.All), nil
+}
+
+// groups turns the active targets into target groups of a single target, at
+// the address and with the scheme Prometheus scrapes it with.
+func (r targetsResponse) groups() []targetGroup {
+	groups := []targetGroup{}
+
+	for _, target := range r.Data.ActiveTargets {
+		scrapeUrl, err := url.Parse(target.ScrapeUrl)
+		if err != nil || scrapeUrl.Host == "" {
+			continue
+		}
+
+		labels := map[string]string{"__scheme__": scrapeUrl.Scheme}
+		for key, value := range target.Labels {
+			labels[key] = value
+		}
+		groups = append(groups, targetGroup{Targets: []string{scrapeUrl.Host}, Labels: labels})
+	}
+
+	return groups
+}
+
+func (p *Prometheus) authorize(request *http.Request) {
+	if p.config.Username != "" {
+		request.SetBasicAuth(p.config.Username, p.config.Password)
+	}
+}
+
+// targetGroupApps turns target groups into applications. Labels like
+// homedash_name describe the application, the URL defaults to the
+// __scheme__ label and the address of each target.
+func targetGroupApps(groups []targetGroup, all bool) []m.ContainerInfo {
+	apps := []m.ContainerInfo{}
+
+	for _, group := range groups {
+		labels := map[string]string{}
+		metaLabels(group.Labels, labels)
+
+		targets := slices.DeleteFunc(slices.Clone(group.Targets), func(target string) bool { return target == "" })
+
+		app, found := appFromLabels(labels)
+		if (!found && !all) || isDisabled(labels) || len(targets) == 0 {
+			continue
+		}
+
+		if app.Name == "" {
+			app.Name = group.Labels["instance"]
+		}
+		if app.Name == "" {
+			app.Name = group.Labels["job"]
+		}
+		if app.Name == "" {
+			app.Name, _, _ = strings.Cut(targets[0], ":")
+		}
+		if app.Icon == "" {
+			app.Icon = c.GuessIcon(group.Labels["job"], app.Name)
+		}
+
+		scheme := group.Labels["__scheme__"]
+		if scheme == "" {
+			scheme = "http"
+		}
+
+		urls := []string{}
+		for _, target := range targets {
+			urls = append(urls, scheme+"://"+target+"/")
+		}
+
+		apps = append(apps, expandUrls(app, urls)...)
+	}
+
+	return apps
+}
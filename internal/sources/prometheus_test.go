@@ -0,0 +1,237 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// newPrometheusStandIn serves testdata/prometheus/targets.json as the targets
+// API of a Prometheus server and http_sd.json as an HTTP SD endpoint.
+func newPrometheusStandIn(t *testing.T) *httptest.Server {
+	files := map[string]string{"/api/v1/targets": "targets.json", "/sd": "http_sd.json"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "homedash" || password != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		file, found := files[r.URL.Path]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "prometheus", file))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTargetGroupApps(t *testing.T) {
+	withIcons(t, c.IconIndex{"grafana": "grafana.svg"})
+
+	data, err := os.ReadFile("testdata/prometheus/targets.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := targetsResponse{}
+	if err := json.Unmarshal(data, &targets); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		all      bool
+		expected []string
+	}{
+		{
+			name: "labeled targets",
+			expected: []string{
+				"Grafana http://grafana:3000/ icon=grafana group=Monitoring",
+				"traefik.example.com:8443 https://traefik.example.com/dashboard/ icon=traefik group=",
+				"nas.lan:9100 http://nas.lan:9100/ icon= group=",
+			},
+		},
+		{
+			name: "all targets",
+			all:  true,
+			expected: []string{
+				"localhost:9090 http://localhost:9090/ icon= group=",
+				"Grafana http://grafana:3000/ icon=grafana group=Monitoring",
+				"traefik.example.com:8443 https://traefik.example.com/dashboard/ icon=traefik group=",
+				"nas.lan:9100 http://nas.lan:9100/ icon= group=",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apps := targetGroupApps(targets.groups(), test.all)
+			if labels := appLabels(apps); !slices.Equal(labels, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, labels)
+			}
+		})
+	}
+
+	apps := targetGroupApps(targets.groups(), false)
+	if rack := apps[2].Metadata["rack"]; rack != "r1" {
+		t.Errorf("expected the metadata from the labels, got %q", rack)
+	}
+}
+
+func TestTargetGroupAppsNames(t *testing.T) {
+	withIcons(t, c.IconIndex{})
+
+	tests := []struct {
+		name     string
+		group    targetGroup
+		expected []string
+	}{
+		{"instance", targetGroup{[]string{"10.0.0.1:9100"}, map[string]string{"instance": "router", "job": "node"}}, []string{"router http://10.0.0.1:9100/"}},
+		{"job", targetGroup{[]string{"10.0.0.1:9100"}, map[string]string{"job": "node"}}, []string{"node http://10.0.0.1:9100/"}},
+		{"target", targetGroup{[]string{"nas.lan:9100"}, nil}, []string{"nas.lan http://nas.lan:9100/"}},
+		{"scheme", targetGroup{[]string{"nas.lan"}, map[string]string{"__scheme__": "https"}}, []string{"nas.lan https://nas.lan/"}},
+		{"no targets", targetGroup{[]string{}, map[string]string{"job": "node"}}, []string{}},
+		{"empty target", targetGroup{[]string{"", "nas.lan"}, nil}, []string{"nas.lan http://nas.lan/"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apps := targetGroupApps([]targetGroup{test.group}, true)
+			if urls := appUrls(apps); !slices.Equal(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
+
+func TestPrometheusFetch(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg", "grafana": "grafana.svg"})
+	server := newPrometheusStandIn(t)
+
+	tests := []struct {
+		name     string
+		config   c.PrometheusSourceConfiguration
+		expected []string
+		err      bool
+	}{
+		{
+			name:   "targets API",
+			config: c.PrometheusSourceConfiguration{Url: server.URL + "/api/v1/targets"},
+			expected: []string{
+				"Grafana http://grafana:3000/",
+				"traefik.example.com:8443 https://traefik.example.com/dashboard/",
+				"nas.lan:9100 http://nas.lan:9100/",
+			},
+		},
+		{
+			name:   "HTTP SD",
+			config: c.PrometheusSourceConfiguration{Url: server.URL + "/sd"},
+			expected: []string{
+				"jellyfin (jellyfin:8096) http://jellyfin:8096/",
+				"jellyfin (jellyfin-backup:8096) http://jellyfin-backup:8096/",
+				"Gitea https://gitea.example.com/",
+			},
+		},
+		{
+			name:   "HTTP SD with all targets",
+			config: c.PrometheusSourceConfiguration{Url: server.URL + "/sd", All: true},
+			expected: []string{
+				"jellyfin (jellyfin:8096) http://jellyfin:8096/",
+				"jellyfin (jellyfin-backup:8096) http://jellyfin-backup:8096/",
+				"Gitea https://gitea.example.com/",
+				"router http://10.0.0.1:9100/",
+			},
+		},
+		{
+			name:   "wrong password",
+			config: c.PrometheusSourceConfiguration{Url: server.URL + "/sd", Password: "wrong"},
+			err:    true,
+		},
+		{
+			name:   "not found",
+			config: c.PrometheusSourceConfiguration{Url: server.URL + "/api/v1/missing"},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Username = "homedash"
+			if test.config.Password == "" {
+				test.config.Password = "secret"
+			}
+			prometheus, err := NewPrometheus(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			apps, err := prometheus.fetch(context.Background())
+			if (err != nil) != test.err {
+				t.Fatalf("expected an error to be %v, got %v", test.err, err)
+			}
+			if test.err {
+				return
+			}
+			if urls := appUrls(apps); !slices.Equal(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
+
+func TestNewPrometheus(t *testing.T) {
+	tests := []struct {
+		name   string
+		config c.PrometheusSourceConfiguration
+		urlErr bool
+		fsErr  bool
+	}{
+		{"url", c.PrometheusSourceConfiguration{Url: "http://prometheus:9090/api/v1/targets"}, false, true},
+		{"paths", c.PrometheusSourceConfiguration{Paths: []string{"targets.yml"}}, true, false},
+		{"both", c.PrometheusSourceConfiguration{Url: "http://prometheus:9090/api/v1/targets", Paths: []string{"targets.yml"}}, true, true},
+		{"neither", c.PrometheusSourceConfiguration{}, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prometheus, err := NewPrometheus(test.config)
+			if (err != nil) != test.urlErr {
+				t.Errorf("expected NewPrometheus to fail to be %v, got %v", test.urlErr, err)
+			}
+			if err == nil && prometheus.config.Interval != 60 {
+				t.Errorf("expected the default interval, got %d", prometheus.config.Interval)
+			}
+
+			if _, err := NewPrometheusFiles(test.config); (err != nil) != test.fsErr {
+				t.Errorf("expected NewPrometheusFiles to fail to be %v, got %v", test.fsErr, err)
+			}
+		})
+	}
+}
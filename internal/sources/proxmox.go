@@ -0,0 +1,211 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+type proxmoxGuest struct {
+	VMID     int    `json:"vmid"`
+	Name     string `json:"name"`
+	Node     string `json:"node"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Tags     string `json:"tags"`
+	Template int    `json:"template"`
+}
+
+type proxmoxGuestConfig struct {
+	Description string `json:"description"`
+}
+
+// Proxmox discovers applications from the VMs and LXC containers of a
+// Proxmox VE node or cluster. Guests are described in their notes or tags.
+type Proxmox struct {
+	poller
+	config c.ProxmoxSourceConfiguration
+	client *http.Client
+}
+
+func NewProxmox(config c.ProxmoxSourceConfiguration) (*Proxmox, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Url == "" {
+		return nil, fmt.Errorf("proxmox source %s: url is required", config.Name)
+	}
+	if config.TokenID == "" || config.Secret == "" {
+		return nil, fmt.Errorf("proxmox source %s: tokenid and secret are required", config.Name)
+	}
+	if config.Interval <= 0 {
+		config.Interval = 60
+	}
+
+	// Proxmox uses a self-signed certificate unless told otherwise
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("proxmox source %s: %w", config.Name, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("proxmox source %s: no certificates found in %s", config.Name, config.CAFile)
+		}
+	}
+	client := newHTTPClient(tlsConfig)
+	client.Timeout = requestTimeout
+
+	return &Proxmox{
+		poller: newPoller("proxmox", config.Name),
+		config: config,
+		client: client,
+	}, nil
+}
+
+func (p *Proxmox) Watch(ctx context.Context, onChange func()) error {
+	p.setOnChange(onChange)
+	go p.poll(ctx, time.Duration(p.config.Interval)*time.Second, p.fetch)
+
+	return nil
+}
+
+func (p *Proxmox) fetch(ctx context.Context) ([]m.ContainerInfo, error) {
+	var resources struct {
+		Data []proxmoxGuest `json:"data"`
+	}
+	if _, err := getJSON(ctx, p.client, p.endpoint("/cluster/resources?type=vm"), p.authorize, &resources); err != nil {
+		return nil, err
+	}
+
+	guests := resources.Data
+	slices.SortFunc(guests, func(a, b proxmoxGuest) int {
+		return a.VMID - b.VMID
+	})
+
+	apps := []m.ContainerInfo{}
+	for _, guest := range guests {
+		if guest.Template == 1 || (len(p.config.Nodes) > 0 && !slices.Contains(p.config.Nodes, guest.Node)) {
+			continue
+		}
+
+		labels, err := p.guestLabels(ctx, guest)
+		if err != nil {
+			return nil, err
+		}
+
+		app, found := appFromLabels(labels)
+		if !found || app.Url == "" {
+			continue
+		}
+
+		if app.Name == "" {
+			app.Name = guest.Name
+		}
+		if app.Icon == "" {
+			app.Icon = c.GuessIcon(guest.Name)
+		}
+		app.Status = guestStatus(guest.Status)
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// guestLabels reads the labels of a guest from its tags, like
+// homedash.icon.jellyfin, and from lines in its notes, like
+// "homedash.url: https://jellyfin.example.com". The notes are only fetched
+// for guests with a homedash tag, unless all guests are checked.
+func (p *Proxmox) guestLabels(ctx context.Context, guest proxmoxGuest) (map[string]string, error) {
+	labels := map[string]string{}
+	tagged := false
+
+	for _, tag := range strings.FieldsFunc(guest.Tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	}) {
+		if tag == "homedash" {
+			tagged = true
+			continue
+		}
+
+		// Tags can't contain "=", so the value follows the field after a dot
+		field, isHomeDash := strings.CutPrefix(tag, labelPrefix)
+		if !isHomeDash {
+			continue
+		}
+		tagged = true
+		if key, value, found := strings.Cut(field, "."); found {
+			labels[labelPrefix+key] = value
+		}
+	}
+
+	if tagged || p.config.All {
+		var guestConfig struct {
+			Data proxmoxGuestConfig `json:"data"`
+		}
+		path := fmt.Sprintf("/nodes/%s/%s/%d/config", url.PathEscape(guest.Node), url.PathEscape(guest.Type), guest.VMID)
+		if _, err := getJSON(ctx, p.client, p.endpoint(path), p.authorize, &guestConfig); err != nil {
+			return nil, err
+		}
+
+		// Notes win over tags, they have room for the full value
+		for line := range strings.SplitSeq(guestConfig.Data.Description, "\n") {
+			commentLabel(line, labels)
+		}
+	}
+
+	// URLs in tags can't have a scheme
+	for _, key := range []string{"url", "internalurl", "externalurl"} {
+		if value := labels[labelPrefix+key]; value != "" && !strings.Contains(value, "://") {
+			labels[labelPrefix+key] = "https://" + value
+		}
+	}
+
+	return labels, nil
+}
+
+// guestStatus maps the status of a guest to an application status. Paused
+// and suspended guests count as degraded.
+func guestStatus(status string) string {
+	switch status {
+	case "running":
+		return m.StatusUp
+	case "stopped":
+		return m.StatusDown
+	case "":
+		return ""
+	}
+
+	return m.StatusDegraded
+}
+
+func (p *Proxmox) endpoint(path string) string {
+	return strings.TrimSuffix(p.config.Url, "/") + "/api2/json" + path
+}
+
+func (p *Proxmox) authorize(request *http.Request) {
+	request.Header.Set("Authorization", "PVEAPIToken="+p.config.TokenID+"="+p.config.Secret)
+}
@@ -0,0 +1,181 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+const proxmoxToken = "PVEAPIToken=homedash@pve!dashboard=secret"
+
+// newProxmoxStandIn serves the JSON files in testdata/proxmox as the Proxmox
+// API, over TLS with a self-signed certificate like Proxmox does.
+func newProxmoxStandIn(t *testing.T) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != proxmoxToken {
+			http.Error(w, "authentication failure", http.StatusUnauthorized)
+			return
+		}
+
+		path, found := strings.CutPrefix(r.URL.Path, "/api2/json/")
+		if !found || (path == "cluster/resources" && r.URL.Query().Get("type") != "vm") {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", "proxmox", filepath.FromSlash(path)+".json"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+
+	// Rejected certificates are expected, don't log the failed handshakes
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestProxmoxGuests(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg"})
+	server := newProxmoxStandIn(t)
+
+	tests := []struct {
+		name     string
+		config   c.ProxmoxSourceConfiguration
+		expected []string
+	}{
+		{
+			name: "tagged guests",
+			expected: []string{
+				"jellyfin https://jellyfin.example.com up",
+				"pihole https://pihole.lan down",
+				"Backups https://backup.example.com degraded",
+			},
+		},
+		{
+			name:   "all guests",
+			config: c.ProxmoxSourceConfiguration{All: true},
+			expected: []string{
+				"jellyfin https://jellyfin.example.com up",
+				"pihole https://pihole.lan down",
+				"nas https://nas.example.com up",
+				"Backups https://backup.example.com degraded",
+			},
+		},
+		{
+			name:   "nodes",
+			config: c.ProxmoxSourceConfiguration{Nodes: []string{"pve2"}},
+			expected: []string{
+				"Backups https://backup.example.com degraded",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Url = server.URL
+			test.config.TokenID = "homedash@pve!dashboard"
+			test.config.Secret = "secret"
+			test.config.Insecure = true
+			proxmox, err := NewProxmox(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			apps, err := proxmox.fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statuses := appStatuses(apps); !slices.Equal(statuses, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, statuses)
+			}
+		})
+	}
+}
+
+func TestProxmoxGuestLabels(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg"})
+	server := newProxmoxStandIn(t)
+
+	proxmox, err := NewProxmox(c.ProxmoxSourceConfiguration{Url: server.URL, TokenID: "homedash@pve!dashboard", Secret: "secret", Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apps, err := proxmox.fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if apps[0].Icon != "jellyfin" || apps[0].Description != "Movies and shows" {
+		t.Errorf("expected the icon from the name and the description from the notes, got %+v", apps[0])
+	}
+	if apps[1].Icon != "pi-hole" {
+		t.Errorf("expected the icon from the tags, got %+v", apps[1])
+	}
+}
+
+func TestProxmoxCertificates(t *testing.T) {
+	server := newProxmoxStandIn(t)
+
+	caFile := filepath.Join(t.TempDir(), "pve-root-ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config c.ProxmoxSourceConfiguration
+		err    bool
+	}{
+		{"self-signed", c.ProxmoxSourceConfiguration{}, true},
+		{"certificate authority", c.ProxmoxSourceConfiguration{CAFile: caFile}, false},
+		{"insecure", c.ProxmoxSourceConfiguration{Insecure: true}, false},
+		{"wrong secret", c.ProxmoxSourceConfiguration{Insecure: true, Secret: "wrong"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Url = server.URL
+			test.config.TokenID = "homedash@pve!dashboard"
+			if test.config.Secret == "" {
+				test.config.Secret = "secret"
+			}
+			proxmox, err := NewProxmox(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := proxmox.fetch(context.Background()); (err != nil) != test.err {
+				t.Errorf("expected an error to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
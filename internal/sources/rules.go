@@ -0,0 +1,61 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ruleMatcherPattern  = regexp.MustCompile(`\b(Host|PathPrefix|Path)\(([^)]*)\)`)
+	ruleArgumentPattern = regexp.MustCompile("`([^`]*)`|\"([^\"]*)\"")
+)
+
+// parseHostRule returns the hosts and the path of a Traefik router rule like
+// "Host(`app.example.com`) && PathPrefix(`/app`)". HostRegexp and paths
+// containing regular expressions are ignored, as there is nothing to link to.
+func parseHostRule(rule string) ([]string, string) {
+	hosts := []string{}
+	path := ""
+
+	for _, matcher := range ruleMatcherPattern.FindAllStringSubmatch(rule, -1) {
+		for _, argument := range ruleArgumentPattern.FindAllStringSubmatch(matcher[2], -1) {
+			value := argument[1] + argument[2]
+
+			switch matcher[1] {
+			case "Host":
+				if value != "" {
+					hosts = append(hosts, strings.ToLower(value))
+				}
+			case "PathPrefix", "Path":
+				if path == "" && !strings.ContainsAny(value, "{}()[]^$") {
+					path = value
+				}
+			}
+		}
+	}
+
+	return hosts, path
+}
+
+// ruleUrls returns a URL for every host in the rule.
+func ruleUrls(rule string, scheme string) []string {
+	hosts, path := parseHostRule(rule)
+
+	urls := []string{}
+	for _, host := range hosts {
+		urls = appendUrl(urls, scheme, host, path)
+	}
+
+	return urls
+}
@@ -0,0 +1,279 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+// Package sources contains the discovery backends that can be enabled next to
+// the sidecars and the static applications.
+package sources
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	s "github.com/mvdkleijn/homedash/internal/services"
+)
+
+// retryInterval is how long a source waits before trying again after failing
+// to reach its backend.
+const retryInterval = 30 * time.Second
+
+// Setup adds every configured source to the DataStore.
+func Setup(ctx context.Context, ds *s.DataStore) error {
+	sources := []s.AppSource{}
+
+	for _, sourceConfig := range c.Config.Sources.Kubernetes {
+		source, err := NewKubernetes(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Traefik {
+		source, err := NewTraefik(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Caddy {
+		source, err := NewCaddy(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Nginx {
+		source, err := NewNginx(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Consul {
+		source, err := NewConsul(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Nomad {
+		source, err := NewNomad(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.MDNS {
+		source, err := NewMDNS(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Proxmox {
+		source, err := NewProxmox(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Prometheus {
+		if len(sourceConfig.Paths) > 0 {
+			source, err := NewPrometheusFiles(sourceConfig)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, source)
+			continue
+		}
+
+		source, err := NewPrometheus(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Compose {
+		source, err := NewCompose(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sourceConfig := range c.Config.Sources.Upstream {
+		source, err := NewUpstream(sourceConfig)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
+	}
+
+	for _, source := range sources {
+		if err := ds.AddSource(ctx, source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandUrls returns a copy of the application for every URL, unless the
+// application has a URL of its own. Copies are named after their URL when
+// there is more than one.
+func expandUrls(app m.ContainerInfo, urls []string) []m.ContainerInfo {
+	if app.Url != "" {
+		return []m.ContainerInfo{app}
+	}
+
+	apps := []m.ContainerInfo{}
+	for i, url := range urls {
+		entry := app
+		entry.Url = url
+		if len(urls) > 1 {
+			entry.Name = app.Name + " (" + strings.TrimSuffix(strings.SplitN(url, "://", 2)[1], "/") + ")"
+			if app.ID != "" && i > 0 {
+				entry.ID = app.ID + "-" + strconv.Itoa(i)
+			}
+		}
+		apps = append(apps, entry)
+	}
+
+	return apps
+}
+
+// poller keeps the applications of a source that fetches them from a backend.
+// It implements the List, Health and Name methods of s.AppSource.
+type poller struct {
+	name string
+	id   string
+
+	mu       sync.Mutex
+	apps     []m.ContainerInfo
+	err      error
+	updated  time.Time
+	onChange func()
+}
+
+func newPoller(name string, instance string) poller {
+	return poller{name: name, id: name + ":" + instance}
+}
+
+func (p *poller) Name() string {
+	return p.name
+}
+
+// List returns the last successfully fetched applications. Sources that serve
+// their own icons set the IconFile, the others get one from the icon index.
+func (p *poller) List() []m.ContainerInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	apps := slices.Clone(p.apps)
+	for i := range apps {
+		apps[i].Sources = []string{p.id}
+		apps[i].Updated = p.updated
+		if apps[i].IconFile == "" {
+			apps[i].IconFile = c.GetIconPath(apps[i].Icon)
+		}
+	}
+
+	return apps
+}
+
+// Health returns the error of the last fetch.
+func (p *poller) Health() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+func (p *poller) setOnChange(onChange func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onChange = onChange
+}
+
+// update stores the result of a fetch. Without applications, the previous
+// ones are kept so a flaky backend doesn't empty the dashboard. Sources that
+// read several files can return the applications they could read together
+// with an error for the others.
+func (p *poller) update(apps []m.ContainerInfo, err error) {
+	p.mu.Lock()
+
+	if err != nil && p.err == nil {
+		c.Logger.Error().Err(err).Str("source", p.id).Msg("failed to fetch applications")
+	}
+	if err == nil && p.err != nil {
+		c.Logger.Info().Str("source", p.id).Msg("source recovered")
+	}
+	p.err = err
+
+	if apps == nil {
+		p.mu.Unlock()
+		return
+	}
+
+	changed := !reflect.DeepEqual(p.apps, apps)
+	p.apps = apps
+	p.updated = time.Now()
+	onChange := p.onChange
+
+	p.mu.Unlock()
+
+	if changed {
+		c.Logger.Debug().Str("source", p.id).Int("apps", len(apps)).Msg("applications changed")
+		if onChange != nil {
+			onChange()
+		}
+	}
+}
+
+// poll fetches the applications every interval until ctx is done.
+func (p *poller) poll(ctx context.Context, interval time.Duration, fetch func(context.Context) ([]m.ContainerInfo, error)) {
+	for {
+		p.update(fetch(ctx))
+
+		if !sleep(ctx, interval) {
+			return
+		}
+	}
+}
+
+// sleep waits for the duration and returns false when ctx is done first.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+func TestMain(tests *testing.M) {
+	logger := zerolog.Nop()
+	c.Logger = &logger
+
+	os.Exit(tests.Run())
+}
+
+// waitForChange fails the test when the source doesn't report a change in
+// time.
+func waitForChange(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the source to report a change")
+	}
+}
+
+// appUrls returns the name and URL of every application, for comparing them
+// in tests.
+func appUrls(apps []m.ContainerInfo) []string {
+	urls := []string{}
+	for _, app := range apps {
+		urls = append(urls, app.Name+" "+app.Url)
+	}
+
+	return urls
+}
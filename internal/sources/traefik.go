@@ -0,0 +1,172 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+)
+
+// traefikInternalProvider serves Traefik's own dashboard and API.
+const traefikInternalProvider = "internal"
+
+type traefikRouter struct {
+	Name        string          `json:"name"`
+	Rule        string          `json:"rule"`
+	EntryPoints []string        `json:"entryPoints"`
+	Service     string          `json:"service"`
+	Provider    string          `json:"provider"`
+	Status      string          `json:"status"`
+	TLS         json.RawMessage `json:"tls"`
+}
+
+// Traefik discovers applications from the HTTP routers known to Traefik.
+type Traefik struct {
+	poller
+	config  c.TraefikSourceConfiguration
+	routers *regexp.Regexp
+}
+
+func NewTraefik(config c.TraefikSourceConfiguration) (*Traefik, error) {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Url == "" {
+		return nil, fmt.Errorf("traefik source %s: url is required", config.Name)
+	}
+	if config.Interval <= 0 {
+		config.Interval = 60
+	}
+
+	traefik := &Traefik{
+		poller: newPoller("traefik", config.Name),
+		config: config,
+	}
+
+	if config.Routers != "" {
+		routers, err := regexp.Compile(config.Routers)
+		if err != nil {
+			return nil, fmt.Errorf("traefik source %s: invalid routers pattern: %w", config.Name, err)
+		}
+		traefik.routers = routers
+	}
+
+	return traefik, nil
+}
+
+func (t *Traefik) Watch(ctx context.Context, onChange func()) error {
+	t.setOnChange(onChange)
+	go t.poll(ctx, time.Duration(t.config.Interval)*time.Second, t.fetch)
+
+	return nil
+}
+
+func (t *Traefik) fetch(ctx context.Context) ([]m.ContainerInfo, error) {
+	routers, err := t.getRouters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apps := []m.ContainerInfo{}
+	for _, router := range routers {
+		if !t.includes(router) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(router.Name, "@")
+		service, _, _ := strings.Cut(router.Service, "@")
+
+		scheme := "http"
+		if len(router.TLS) > 0 && string(router.TLS) != "null" {
+			scheme = "https"
+		}
+
+		app := m.ContainerInfo{
+			Name: name,
+			Icon: c.GuessIcon(service, name),
+		}
+		apps = append(apps, expandUrls(app, ruleUrls(router.Rule, scheme))...)
+	}
+
+	return apps, nil
+}
+
+// getRouters reads all pages of routers.
+func (t *Traefik) getRouters(ctx context.Context) ([]traefikRouter, error) {
+	routers := []traefikRouter{}
+
+	for page := 1; ; {
+		var pageRouters []traefikRouter
+
+		endpoint := strings.TrimSuffix(t.config.Url, "/") + "/api/http/routers?page=" + strconv.Itoa(page)
+		header, err := getJSON(ctx, httpClient, endpoint, t.authorize, &pageRouters)
+		if err != nil {
+			return nil, err
+		}
+		routers = append(routers, pageRouters...)
+
+		next, err := strconv.Atoi(header.Get("X-Next-Page"))
+		if err != nil || next <= page {
+			return routers, nil
+		}
+		page = next
+	}
+}
+
+func (t *Traefik) authorize(request *http.Request) {
+	if t.config.Username != "" {
+		request.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+}
+
+// includes reports whether the router passes the configured filters. Traefik's
+// own routers are skipped unless the internal provider is asked for.
+func (t *Traefik) includes(router traefikRouter) bool {
+	if router.Status != "" && router.Status != "enabled" {
+		return false
+	}
+
+	provider := router.Provider
+	if provider == "" {
+		_, provider, _ = strings.Cut(router.Name, "@")
+	}
+
+	if len(t.config.Providers) > 0 {
+		if !slices.Contains(t.config.Providers, provider) {
+			return false
+		}
+	} else if provider == traefikInternalProvider {
+		return false
+	}
+
+	if len(t.config.EntryPoints) > 0 && !slices.ContainsFunc(router.EntryPoints, func(entryPoint string) bool {
+		return slices.Contains(t.config.EntryPoints, entryPoint)
+	}) {
+		return false
+	}
+
+	if t.routers != nil && !t.routers.MatchString(router.Name) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,198 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// newTraefikStandIn serves the recorded routers of a Traefik instance, three
+// per page, behind basic authentication.
+func newTraefikStandIn(t *testing.T) *httptest.Server {
+	data, err := os.ReadFile("testdata/traefik/routers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var routers []json.RawMessage
+	if err := json.Unmarshal(data, &routers); err != nil {
+		t.Fatal(err)
+	}
+
+	const perPage = 3
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "admin" || password != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/http/routers" {
+			http.NotFound(w, r)
+			return
+		}
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		start := min((page-1)*perPage, len(routers))
+		end := min(start+perPage, len(routers))
+
+		// Like Traefik, the last page points back to the first one
+		next := 1
+		if end < len(routers) {
+			next = page + 1
+		}
+		w.Header().Set("X-Next-Page", strconv.Itoa(next))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(routers[start:end])
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// withIcons sets the icon index for the duration of a test.
+func withIcons(t *testing.T, icons c.IconIndex) {
+	previous := c.Index
+	c.Index = icons
+	t.Cleanup(func() { c.Index = previous })
+}
+
+func TestTraefikRouters(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg", "grafana": "grafana.svg"})
+	server := newTraefikStandIn(t)
+
+	tests := []struct {
+		name     string
+		config   c.TraefikSourceConfiguration
+		expected []string
+	}{
+		{
+			name: "all routers",
+			expected: []string{
+				"jellyfin https://jellyfin.example.com/",
+				"grafana (grafana.lan) http://grafana.lan/",
+				"grafana (grafana.example.com) http://grafana.example.com/",
+				"nas https://nas.example.com/files",
+			},
+		},
+		{
+			name:   "provider",
+			config: c.TraefikSourceConfiguration{Providers: []string{"file"}},
+			expected: []string{
+				"nas https://nas.example.com/files",
+			},
+		},
+		{
+			name:   "entrypoint",
+			config: c.TraefikSourceConfiguration{EntryPoints: []string{"websecure"}},
+			expected: []string{
+				"jellyfin https://jellyfin.example.com/",
+				"nas https://nas.example.com/files",
+			},
+		},
+		{
+			name:   "router pattern",
+			config: c.TraefikSourceConfiguration{Routers: "^grafana@"},
+			expected: []string{
+				"grafana (grafana.lan) http://grafana.lan/",
+				"grafana (grafana.example.com) http://grafana.example.com/",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Url = server.URL + "/"
+			test.config.Username = "admin"
+			test.config.Password = "secret"
+			traefik, err := NewTraefik(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			apps, err := traefik.fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if urls := appUrls(apps); !slices.Equal(urls, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, urls)
+			}
+		})
+	}
+}
+
+func TestTraefikGuessesIcons(t *testing.T) {
+	withIcons(t, c.IconIndex{"jellyfin": "jellyfin.svg", "grafana": "grafana.svg"})
+	server := newTraefikStandIn(t)
+
+	traefik, err := NewTraefik(c.TraefikSourceConfiguration{Url: server.URL, Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apps, err := traefik.fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	icons := map[string]string{}
+	for _, app := range apps {
+		icons[app.Url] = app.Icon
+	}
+	if icons["https://jellyfin.example.com/"] != "jellyfin" || icons["http://grafana.lan/"] != "grafana" || icons["https://nas.example.com/files"] != "" {
+		t.Errorf("unexpected icons %v", icons)
+	}
+}
+
+func TestTraefikUnauthorized(t *testing.T) {
+	server := newTraefikStandIn(t)
+
+	traefik, err := NewTraefik(c.TraefikSourceConfiguration{Url: server.URL, Username: "admin", Password: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := traefik.fetch(context.Background()); err == nil {
+		t.Error("expected an error for rejected credentials")
+	}
+}
+
+func TestParseHostRule(t *testing.T) {
+	tests := []struct {
+		rule  string
+		hosts []string
+		path  string
+	}{
+		{"Host(`app.example.com`)", []string{"app.example.com"}, ""},
+		{"Host(`App.Example.com`) && PathPrefix(`/app`)", []string{"app.example.com"}, "/app"},
+		{"Host(`a.example.com`, `b.example.com`)", []string{"a.example.com", "b.example.com"}, ""},
+		{"Host(\"a.example.com\") || Host(`b.example.com`)", []string{"a.example.com", "b.example.com"}, ""},
+		{"Host(`app.example.com`) && PathPrefix(`/{id:[0-9]+}`)", []string{"app.example.com"}, ""},
+		{"HostRegexp(`{name:.+}.example.com`)", []string{}, ""},
+	}
+
+	for _, test := range tests {
+		hosts, path := parseHostRule(test.rule)
+		if !slices.Equal(hosts, test.hosts) || path != test.path {
+			t.Errorf("%s: expected %v and %q, got %v and %q", test.rule, test.hosts, test.path, hosts, path)
+		}
+	}
+}
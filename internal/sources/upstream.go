@@ -0,0 +1,322 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	s "github.com/mvdkleijn/homedash/internal/services"
+)
+
+const (
+	upstreamSource = "upstream"
+
+	groupingSite   = "site"
+	groupingPrefix = "prefix"
+)
+
+// upstreamNamePattern keeps the names usable in the paths of proxied icons.
+var upstreamNamePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// upstreams holds the upstream sources by name, for proxying their icons.
+var (
+	upstreams   = map[string]*Upstream{}
+	upstreamsMu sync.RWMutex
+)
+
+// Upstream aggregates the applications of another HomeDash instance. It
+// long-polls the applications API of the instance, so changes show up right
+// away, and keeps showing the last applications as stale while the instance
+// can't be reached.
+type Upstream struct {
+	poller
+	config c.UpstreamSourceConfiguration
+
+	// last holds the applications of the last successful request
+	last []m.ContainerInfo
+}
+
+func NewUpstream(config c.UpstreamSourceConfiguration) (*Upstream, error) {
+	if !upstreamNamePattern.MatchString(config.Name) {
+		return nil, fmt.Errorf("upstream source %q: name is required and may only contain a-z, 0-9 and -", config.Name)
+	}
+	if config.Url == "" {
+		return nil, fmt.Errorf("upstream source %s: url is required", config.Name)
+	}
+	if config.Site == "" {
+		config.Site = config.Name
+	}
+	switch config.Grouping {
+	case "":
+		config.Grouping = groupingSite
+	case groupingSite, groupingPrefix:
+	default:
+		return nil, fmt.Errorf("upstream source %s: invalid grouping %q, expected site or prefix", config.Name, config.Grouping)
+	}
+	if config.Interval <= 0 {
+		config.Interval = 60
+	}
+	config.Url = strings.TrimSuffix(config.Url, "/")
+
+	u := &Upstream{
+		poller: newPoller(upstreamSource, config.Name),
+		config: config,
+	}
+
+	upstreamsMu.Lock()
+	defer upstreamsMu.Unlock()
+	if _, exists := upstreams[config.Name]; exists {
+		return nil, fmt.Errorf("upstream source %s: name is used more than once", config.Name)
+	}
+	upstreams[config.Name] = u
+
+	return u, nil
+}
+
+func (u *Upstream) Watch(ctx context.Context, onChange func()) error {
+	u.setOnChange(onChange)
+	go u.run(ctx)
+
+	return nil
+}
+
+// run fetches the applications whenever the instance reports a change.
+func (u *Upstream) run(ctx context.Context) {
+	wait := time.Duration(u.config.Interval) * time.Second
+	etag := ""
+	for {
+		started := time.Now()
+		apps, newETag, err := u.fetch(ctx, etag)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			u.update(u.stale(), err)
+			// Fetch the whole list again once the instance is back
+			etag = ""
+			if !sleep(ctx, retryInterval) {
+				return
+			}
+			continue
+		}
+
+		if apps != nil {
+			u.last = apps
+			u.update(apps, nil)
+		}
+
+		// Without an ETag, or when an unchanged list comes back before the
+		// wait is over, the instance (or a proxy in front of it) doesn't
+		// long-poll. Ask once per interval instead of right away.
+		if newETag == "" || (newETag == etag && time.Since(started) < wait) {
+			if !sleep(ctx, time.Until(started.Add(wait))) {
+				return
+			}
+		}
+		etag = newETag
+	}
+}
+
+// fetch requests the applications, waiting for a change when etag is set. It
+// returns nil applications when nothing changed.
+func (u *Upstream) fetch(ctx context.Context, etag string) ([]m.ContainerInfo, string, error) {
+	wait := time.Duration(u.config.Interval) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, wait+requestTimeout)
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/api/v1/applications?wait=%ds", u.config.Url, u.config.Interval)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, etag, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	u.authorize(request)
+
+	response, err := blockingClient.Do(request)
+	if err != nil {
+		return nil, etag, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, nil
+	case http.StatusOK:
+	default:
+		return nil, etag, fmt.Errorf("unexpected status %s from %s", response.Status, request.URL.Redacted())
+	}
+
+	remote := []m.ContainerInfo{}
+	if err := json.NewDecoder(response.Body).Decode(&remote); err != nil {
+		return nil, etag, fmt.Errorf("invalid response from %s: %w", request.URL.Redacted(), err)
+	}
+
+	return u.siteApps(remote), response.Header.Get("ETag"), nil
+}
+
+// siteApps prepares the applications of the instance for this dashboard.
+// Applications the instance itself gets from an upstream are skipped, so two
+// instances can aggregate each other without looping.
+func (u *Upstream) siteApps(remote []m.ContainerInfo) []m.ContainerInfo {
+	apps := []m.ContainerInfo{}
+
+	for _, app := range remote {
+		if slices.ContainsFunc(app.Sources, func(id string) bool {
+			return s.SourceName(id) == upstreamSource
+		}) {
+			continue
+		}
+
+		if app.ID != "" {
+			app.ID = u.config.Name + "-" + app.ID
+		}
+
+		if u.config.Grouping == groupingPrefix && app.Group != "" {
+			app.Group = u.config.Site + " / " + app.Group
+		} else {
+			app.Group = u.config.Site
+		}
+
+		metadata := map[string]string{}
+		for key, value := range app.Metadata {
+			metadata[key] = value
+		}
+		metadata["site"] = u.config.Site
+		app.Metadata = metadata
+
+		// Icons from the icon cache of the instance are proxied, for the
+		// default icon this dashboard uses its own
+		if filename, found := strings.CutPrefix(app.IconFile, "/icons/"); found {
+			app.IconFile = "/icons/upstream/" + u.config.Name + "/" + filename
+		} else {
+			app.IconFile = ""
+		}
+
+		app.Sources = nil
+		app.Updated = time.Time{}
+		app.Added = time.Time{}
+
+		apps = append(apps, app)
+	}
+
+	return apps
+}
+
+// stale returns the last applications marked as stale, or nil when there
+// are none.
+func (u *Upstream) stale() []m.ContainerInfo {
+	if u.last == nil {
+		return nil
+	}
+
+	apps := slices.Clone(u.last)
+	for i := range apps {
+		apps[i].Status = m.StatusStale
+	}
+
+	return apps
+}
+
+func (u *Upstream) authorize(request *http.Request) {
+	if u.config.APIKey != "" {
+		request.Header.Set("Authorization", "Bearer "+u.config.APIKey)
+	}
+}
+
+// iconPolicy keeps scripts in proxied SVG icons from running, as they are
+// served from the origin of this dashboard.
+const iconPolicy = "default-src 'none'; style-src 'unsafe-inline'"
+
+// ServeUpstreamIcon proxies an icon from the icon cache of an upstream
+// HomeDash instance. Only images are passed on, so a compromised or
+// misconfigured upstream can't serve pages from this origin.
+func ServeUpstreamIcon(w http.ResponseWriter, r *http.Request) {
+	upstreamsMu.RLock()
+	u, exists := upstreams[r.PathValue("name")]
+	upstreamsMu.RUnlock()
+
+	filename := r.PathValue("filename")
+	if !exists || filename == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if strings.Contains(filename, "/") || strings.Contains(filename, "\\") || strings.Contains(filename, "..") {
+		http.Error(w, "Invalid file name", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.config.Url+"/icons/"+url.PathEscape(filename), nil)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	for _, header := range []string{"If-None-Match", "If-Modified-Since"} {
+		if value := r.Header.Get(header); value != "" {
+			request.Header.Set(header, value)
+		}
+	}
+	u.authorize(request)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		c.Logger.Debug().Err(err).Str("source", u.id).Str("filename", filename).Msg("failed to proxy icon")
+		http.Error(w, "upstream not reachable", http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
+		http.NotFound(w, r)
+		return
+	}
+
+	if response.StatusCode == http.StatusOK {
+		mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+		if err != nil || !strings.HasPrefix(mediaType, "image/") {
+			c.Logger.Warn().Str("source", u.id).Str("filename", filename).Str("contentType", response.Header.Get("Content-Type")).Msg("upstream icon is not an image")
+			http.Error(w, "upstream icon is not an image", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", mediaType)
+	}
+
+	for _, header := range []string{"Content-Length", "Cache-Control", "ETag", "Last-Modified"} {
+		if value := response.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", iconPolicy)
+	w.WriteHeader(response.StatusCode)
+	io.Copy(w, response.Body)
+}
@@ -0,0 +1,267 @@
+/*
+	HomeDash - A simple, automated dashboard for home labs.
+	Copyright (C) 2023-2026  Martijn van der Kleijn
+
+	This file is part of HomeDash.
+
+	This Source Code Form is subject to the terms of the Mozilla Public
+	License, v. 2.0. If a copy of the MPL was not distributed with this
+	file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	c "github.com/mvdkleijn/homedash/internal/config"
+)
+
+// newTestUpstream registers an upstream source for the server for the
+// duration of a test.
+func newTestUpstream(t *testing.T, name string, server string) *Upstream {
+	u, err := NewUpstream(c.UpstreamSourceConfiguration{Name: name, Url: server, APIKey: "test-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		upstreamsMu.Lock()
+		delete(upstreams, name)
+		upstreamsMu.Unlock()
+	})
+
+	return u
+}
+
+func TestServeUpstreamIcon(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"jellyfin"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/icons/jellyfin.svg":
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			w.Header().Set("ETag", `"jellyfin"`)
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(document.cookie)</script></svg>`))
+		case "/icons/page.png":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<script>alert(document.cookie)</script>`))
+		case "/icons/untyped.png":
+			w.Header()["Content-Type"] = nil
+			w.Write([]byte(`<script>alert(document.cookie)</script>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	newTestUpstream(t, "ams", upstream.URL)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /icons/upstream/{name}/{filename}", ServeUpstreamIcon)
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		status      int
+		contentType string
+	}{
+		{"svg", "/icons/upstream/ams/jellyfin.svg", "", http.StatusOK, "image/svg+xml"},
+		{"not modified", "/icons/upstream/ams/jellyfin.svg", `"jellyfin"`, http.StatusNotModified, ""},
+		{"html", "/icons/upstream/ams/page.png", "", http.StatusBadGateway, ""},
+		{"without content type", "/icons/upstream/ams/untyped.png", "", http.StatusBadGateway, ""},
+		{"missing", "/icons/upstream/ams/missing.png", "", http.StatusNotFound, ""},
+		{"unknown upstream", "/icons/upstream/fra/jellyfin.svg", "", http.StatusNotFound, ""},
+		{"parent directory", "/icons/upstream/ams/..jellyfin.svg", "", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, recorder.Code)
+			}
+			if test.status != http.StatusOK && test.status != http.StatusNotModified {
+				return
+			}
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != test.contentType {
+				t.Errorf("expected content type %q, got %q", test.contentType, contentType)
+			}
+			if recorder.Header().Get("X-Content-Type-Options") != "nosniff" || recorder.Header().Get("Content-Security-Policy") != iconPolicy {
+				t.Errorf("expected nosniff and the icon policy, got %v", recorder.Header())
+			}
+		})
+	}
+}
+
+// upstreamStandIn serves the applications API of a HomeDash instance. Unless
+// it ignores waiting, a request with the current ETag blocks until the
+// applications change.
+type upstreamStandIn struct {
+	*httptest.Server
+
+	// withoutETag leaves out the ETag, ignoreWait answers requests with the
+	// current ETag right away
+	withoutETag bool
+	ignoreWait  bool
+
+	mu          sync.Mutex
+	name        string
+	version     int
+	changed     chan struct{}
+	ifNoneMatch []string
+}
+
+func newUpstreamStandIn(t *testing.T, withoutETag bool, ignoreWait bool) *upstreamStandIn {
+	standIn := &upstreamStandIn{withoutETag: withoutETag, ignoreWait: ignoreWait, name: "Jellyfin", version: 1, changed: make(chan struct{})}
+	standIn.Server = httptest.NewServer(http.HandlerFunc(standIn.serve))
+	t.Cleanup(standIn.Close)
+
+	return standIn
+}
+
+func (standIn *upstreamStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/applications" || r.URL.Query().Get("wait") != "1s" {
+		http.NotFound(w, r)
+		return
+	}
+
+	standIn.mu.Lock()
+	standIn.ifNoneMatch = append(standIn.ifNoneMatch, r.Header.Get("If-None-Match"))
+	etag, changed := fmt.Sprintf(`"v%d"`, standIn.version), standIn.changed
+	standIn.mu.Unlock()
+
+	if r.Header.Get("If-None-Match") == etag {
+		if standIn.ignoreWait {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if !standIn.withoutETag {
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, standIn.version))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `[{"id": "jellyfin", "name": %q, "url": "https://jellyfin.example.com"}]`, standIn.name)
+}
+
+// rename changes the name of the application and wakes waiting requests.
+func (standIn *upstreamStandIn) rename(name string) {
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	standIn.name = name
+	standIn.version++
+	close(standIn.changed)
+	standIn.changed = make(chan struct{})
+}
+
+func (standIn *upstreamStandIn) requests() []string {
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	return append([]string{}, standIn.ifNoneMatch...)
+}
+
+// watchUpstream starts polling the stand-in with an interval of a second and
+// waits for the first applications.
+func watchUpstream(t *testing.T, name string, standIn *upstreamStandIn) (*Upstream, <-chan struct{}) {
+	u := newTestUpstream(t, name, standIn.URL)
+	u.config.Interval = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	changes := make(chan struct{}, 10)
+	if err := u.Watch(ctx, func() { changes <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, changes)
+
+	return u, changes
+}
+
+func TestUpstreamLongPolls(t *testing.T) {
+	standIn := newUpstreamStandIn(t, false, false)
+	u, changes := watchUpstream(t, "ams", standIn)
+
+	standIn.rename("Movies")
+	waitForChange(t, changes)
+
+	if urls := appUrls(u.List()); !reflect.DeepEqual(urls, []string{"Movies https://jellyfin.example.com"}) {
+		t.Errorf("expected the renamed application, got %v", urls)
+	}
+
+	// Every request sends the ETag of the last response, also after a 304
+	time.Sleep(1500 * time.Millisecond)
+	requests := standIn.requests()
+	expected := []string{"", `"v1"`, `"v2"`, `"v2"`}
+	if len(requests) < len(expected) || !reflect.DeepEqual(requests[:len(expected)], expected) {
+		t.Errorf("expected If-None-Match %q, got %q", expected, requests)
+	}
+}
+
+func TestUpstreamWithoutLongPolling(t *testing.T) {
+	tests := []struct {
+		name        string
+		upstream    string
+		withoutETag bool
+		ignoreWait  bool
+		ifNoneMatch string
+	}{
+		{name: "immediate 304", upstream: "fra", ignoreWait: true, ifNoneMatch: `"v1"`},
+		{name: "without ETag", upstream: "lon", withoutETag: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			standIn := newUpstreamStandIn(t, test.withoutETag, test.ignoreWait)
+			u, _ := watchUpstream(t, test.upstream, standIn)
+			time.Sleep(1500 * time.Millisecond)
+
+			// Polling once per interval makes two requests, a busy loop many more
+			requests := standIn.requests()
+			if len(requests) < 2 || len(requests) > 3 {
+				t.Fatalf("expected 2 or 3 requests, got %d", len(requests))
+			}
+			if requests[1] != test.ifNoneMatch {
+				t.Errorf("expected If-None-Match %q, got %q", test.ifNoneMatch, requests[1])
+			}
+			if urls := appUrls(u.List()); !reflect.DeepEqual(urls, []string{"Jellyfin https://jellyfin.example.com"}) {
+				t.Errorf("expected the application, got %v", urls)
+			}
+		})
+	}
+}
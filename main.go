@@ -12,9 +12,11 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"embed"
 	"fmt"
+	"html"
 	"net/http"
 	"os"
 	"os/signal"
@@ -23,8 +25,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/mvdkleijn/homedash/internal/auth"
 	c "github.com/mvdkleijn/homedash/internal/config"
+	"github.com/mvdkleijn/homedash/internal/mdns"
+	m "github.com/mvdkleijn/homedash/internal/models"
+	"github.com/mvdkleijn/homedash/internal/network"
+	"github.com/mvdkleijn/homedash/internal/repositories"
 	"github.com/mvdkleijn/homedash/internal/routes"
+	"github.com/mvdkleijn/homedash/internal/services"
+	"github.com/mvdkleijn/homedash/internal/sources"
 )
 
 //go:embed static
@@ -77,7 +86,7 @@ func SimpleCorsMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Origin", strings.Join(c.Config.Cors.AllowedOrigins, ","))
 		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.Config.Cors.AllowedMethods, ","))
 		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.Config.Cors.AllowedHeaders, ","))
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Length,ETag,X-Total-Count")
 		w.Header().Set("Access-Control-Allow-Credentials", strconv.FormatBool(c.Config.Cors.AllowCredentials))
 
 		//TODO make this settable?
@@ -93,6 +102,50 @@ func SimpleCorsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// APIKeyMiddleware adds the identity of a configured API key, as used by
+// sidecars and scripts, to the request context.
+func APIKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if identity := auth.APIKeyIdentity(r); identity != nil {
+			r = r.WithContext(auth.NewContext(r.Context(), identity))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ForwardAuthMiddleware adds the identity set by a trusted forward-auth proxy
+// to the request context, so handlers can use it.
+func ForwardAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.FromContext(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if identity := auth.ForwardedIdentity(r); identity != nil {
+			c.Logger.Debug().Str("user", identity.User).Strs("groups", identity.Groups).Msg("forwarded identity")
+			r = r.WithContext(auth.NewContext(r.Context(), identity))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SessionMiddleware adds the identity of a user logged in through the built-in
+// OpenID Connect support to the request context.
+func SessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.Config.Auth.OIDC.Enabled && auth.FromContext(r.Context()) == nil {
+			if identity := auth.SessionIdentity(r); identity != nil {
+				r = r.WithContext(auth.NewContext(r.Context(), identity))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // RecoveryMiddleware replaces the r.Use(func...) logic
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -106,9 +159,85 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// serveIndex serves the dashboard page, using the title of the dashboard
+// when it has one.
+func serveIndex(w http.ResponseWriter, dashboard m.Dashboard) {
+	indexHtml, err := staticFS.ReadFile("static/index.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if dashboard.Title != "" {
+		indexHtml = bytes.Replace(indexHtml, []byte("<title>My Home Dashboard</title>"), []byte("<title>"+html.EscapeString(dashboard.Title)+"</title>"), 1)
+	}
+
+	w.Write(indexHtml)
+}
+
+// advertise announces HomeDash on the local network until ctx is done.
+func advertise(ctx context.Context) {
+	port, err := strconv.Atoi(c.Config.Server.Port)
+	if err != nil {
+		c.Logger.Error().Err(err).Msg("can't advertise over mDNS without a numeric port")
+		return
+	}
+
+	service := mdns.Service{
+		Instance: c.Config.Server.MDNS.Name,
+		Service:  "_http._tcp",
+		Port:     port,
+		Text:     []string{"path=/"},
+	}
+	c.Logger.Info().Str("name", service.Instance).Msg("advertising over mDNS")
+
+	if err := mdns.Advertise(ctx, service, c.Config.Server.MDNS.Interface); err != nil {
+		c.Logger.Error().Err(err).Msg("failed to advertise over mDNS")
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	c.Setup()
 
+	if err := network.Setup(); err != nil {
+		c.Logger.Fatal().Err(err).Msg("failed to initialize network configuration")
+	}
+
+	if err := auth.Setup(); err != nil {
+		c.Logger.Fatal().Err(err).Msg("failed to initialize authorization")
+	}
+
+	if err := repositories.SetupStore(); err != nil {
+		c.Logger.Fatal().Err(err).Msg("failed to load the application store")
+	}
+
+	repositories.AppsDir.Validate = services.ValidateApp
+	if err := repositories.SetupAppsDir(); err != nil {
+		c.Logger.Fatal().Err(err).Msg("failed to load the apps directory")
+	}
+
+	// Sources stop watching for changes when the server shuts down
+	sourcesCtx, stopSources := context.WithCancel(context.Background())
+	defer stopSources()
+
+	for _, source := range []services.AppSource{&services.StaticApps{}, routes.Sidecars} {
+		if err := routes.DataStore.AddSource(sourcesCtx, source); err != nil {
+			c.Logger.Fatal().Err(err).Msg("failed to add application source")
+		}
+	}
+
+	if err := sources.Setup(sourcesCtx, routes.DataStore); err != nil {
+		c.Logger.Fatal().Err(err).Msg("failed to initialize discovery sources")
+	}
+
+	if c.Config.Server.MDNS.Advertise {
+		go advertise(sourcesCtx)
+	}
+
 	// Create the base mux
 	mux := http.NewServeMux()
 
@@ -119,30 +248,59 @@ func main() {
 		c.Logger.Fatal().Err(err).Msg("failed to initialize routes")
 	}
 
+	// Define the OpenID Connect login routes
+	if c.Config.Auth.OIDC.Enabled {
+		oidc := &routes.OIDC{Provider: auth.NewOIDCProvider()}
+		if err := oidc.AddRoutes(mux); err != nil {
+			c.Logger.Fatal().Err(err).Msg("failed to initialize login routes")
+		}
+	}
+
 	// Define Static Assets
 	fileServer := http.FileServer(http.FS(staticFS))
 	mux.Handle("GET /static/", fileServer)
 
 	// Define Icon route
 	mux.HandleFunc("GET /icons/{filename}", routes.ServeIcon)
+	mux.HandleFunc("GET /icons/upstream/{name}/{filename}", sources.ServeUpstreamIcon)
 
 	// Define Index route
-	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /", routes.RequireLogin(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
 			http.NotFound(w, r)
 			return
 		}
-		indexHtml, err := staticFS.ReadFile("static/index.html")
+		// Use the dashboard profile of the requested host, if any
+		dashboard, _ := services.GetDashboardForHost(services.NewVisitor(r))
+		serveIndex(w, dashboard)
+	}))
+
+	// Define Admin route
+	mux.HandleFunc("GET /admin", routes.RequireLogin(auth.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		adminHtml, err := staticFS.ReadFile("static/admin.html")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		w.Write(indexHtml)
-	})
+		w.Write(adminHtml)
+	}))
+
+	// Define Dashboard routes, these use the same page as the index
+	mux.HandleFunc("GET /d/{name}", routes.RequireLogin(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		dashboard, exists := services.GetDashboard(r.PathValue("name"))
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		serveIndex(w, dashboard)
+	}))
 
 	// Wrap the entire mux with Middleware (The "Chain")
-	// The order is: Recovery -> Logging -> CORS -> Mux
+	// The order is: Recovery -> Logging -> CORS -> APIKey -> ForwardAuth -> Session -> Mux
 	var handler http.Handler = mux
+	handler = SessionMiddleware(handler)
+	handler = ForwardAuthMiddleware(handler)
+	handler = APIKeyMiddleware(handler)
 	handler = SimpleCorsMiddleware(handler)
 	handler = LoggingMiddleware(handler)
 	handler = RecoveryMiddleware(handler)
@@ -151,7 +309,7 @@ func main() {
 	go func() {
 		for {
 			time.Sleep(time.Duration(c.Config.CleanCheckInterval) * time.Minute)
-			routes.DataStore.CleanupOutdatedEntries(c.Config.MaxAgeBeforeCleanup)
+			routes.Sidecars.CleanupOutdatedEntries(c.Config.MaxAgeBeforeCleanup)
 		}
 	}()
 
@@ -177,6 +335,7 @@ func main() {
 	// Wait for interrupt signal
 	<-quit
 	c.Logger.Info().Msg("shutting down server...")
+	stopSources()
 
 	// Create a context with a timeout for the shutdown process
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)